@@ -1,12 +1,11 @@
 package fileutils
 
 import (
-	"bufio"
 	"fmt"
-	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 )
 
 // FileInfo represents a file or directory in the file system
@@ -19,229 +18,24 @@ type FileInfo struct {
 	Selected   bool
 	Children   []*FileInfo
 	TokenCount int // Estimated token count
-}
-
-// gitignorePatterns holds the patterns from .gitignore files
-type gitignorePatterns struct {
-	patterns []string
-}
-
-// newGitignorePatterns creates a new gitignorePatterns instance
-func newGitignorePatterns(rootDir string) (*gitignorePatterns, error) {
-	gitignore := &gitignorePatterns{
-		patterns: []string{},
-	}
-	
-	// Load the .gitignore file from the root directory
-	gitignorePath := filepath.Join(rootDir, ".gitignore")
-	if _, err := os.Stat(gitignorePath); err == nil {
-		if err := gitignore.loadGitignoreFile(gitignorePath); err != nil {
-			return nil, err
-		}
-	}
-	
-	return gitignore, nil
-}
-
-// loadGitignoreFile loads patterns from a .gitignore file
-func (g *gitignorePatterns) loadGitignoreFile(path string) error {
-	file, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-	
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-		
-		// Add the pattern
-		g.patterns = append(g.patterns, line)
-	}
-	
-	return scanner.Err()
-}
-
-// shouldIgnore checks if a file should be ignored based on gitignore patterns
-func (g *gitignorePatterns) shouldIgnore(path string, isDir bool) bool {
-	// Convert path to use forward slashes for consistency with gitignore patterns
-	path = filepath.ToSlash(path)
-	
-	for _, pattern := range g.patterns {
-		// Handle negation (patterns starting with !)
-		negate := false
-		if strings.HasPrefix(pattern, "!") {
-			negate = true
-			pattern = pattern[1:]
-		}
-		
-		// Handle directory-specific patterns (ending with /)
-		dirOnly := false
-		if strings.HasSuffix(pattern, "/") {
-			dirOnly = true
-			pattern = pattern[:len(pattern)-1]
-		}
-		
-		// Skip directory-only patterns if this is a file
-		if dirOnly && !isDir {
-			continue
-		}
-		
-		// Handle simple glob patterns
-		matched := false
-		
-		// Exact match
-		if path == pattern {
-			matched = true
-		}
-		
-		// Match with wildcards
-		if strings.Contains(pattern, "*") {
-			if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
-				return !negate
-			}
-			
-			// Handle ** pattern (match any directory)
-			if strings.Contains(pattern, "**") {
-				// Replace ** with a placeholder that won't match normal characters
-				patternRegex := strings.Replace(pattern, "**", ".*", -1)
-				patternRegex = strings.Replace(patternRegex, "*", "[^/]*", -1)
-				patternRegex = "^" + patternRegex + "$"
-				
-				// Simple check: if the pattern is a prefix of the path
-				if strings.HasPrefix(path, strings.TrimSuffix(pattern, "/**")) {
-					matched = true
-				}
-			}
-		}
-		
-		// Handle directory prefix patterns
-		if !matched && strings.Contains(pattern, "/") {
-			if strings.HasPrefix(path, pattern) {
-				matched = true
-			}
-		}
-		
-		// If the pattern matches, respect negation
-		if matched {
-			return !negate
-		}
-	}
-	
-	return false
+	ModTime    time.Time
+	Ignored    bool   // Excluded by an Ignorer, but still shown (greyed out) rather than hidden
+	IgnoreRule string // Human-readable rule that caused Ignored, e.g. ".gitignore: node_modules/"
 }
 
 // FileFilters represents filters for file selection
 type FileFilters struct {
-	Extensions     []string
-	NamePattern    string
-	IgnorePatterns []string
-	SubPath        string // Path relative to the root directory
-	RespectGitignore bool // Whether to respect .gitignore files
-}
-
-// ListFiles returns a list of files in the given directory
-func ListFiles(dir string, filters FileFilters) ([]*FileInfo, error) {
-	var result []*FileInfo
-	
-	// If SubPath is specified, adjust the directory
-	if filters.SubPath != "" {
-		dir = filepath.Join(dir, filters.SubPath)
-	}
-	
-	// Load gitignore patterns if needed
-	var gitignore *gitignorePatterns
-	var err error
-	if filters.RespectGitignore {
-		gitignore, err = newGitignorePatterns(dir)
-		if err != nil {
-			return nil, err
-		}
-	}
-	
-	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // Skip inaccessible paths
-		}
-		
-		// Get relative path for gitignore checking
-		relPath, relErr := filepath.Rel(dir, path)
-		if relErr != nil {
-			relPath = path
-		}
-		
-		// Skip if matches gitignore patterns
-		if filters.RespectGitignore && gitignore != nil && path != dir {
-			if gitignore.shouldIgnore(relPath, info.IsDir()) {
-				if info.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
-			}
-		}
-		
-		// Skip if matches ignore patterns
-		for _, pattern := range filters.IgnorePatterns {
-			matched, err := filepath.Match(pattern, info.Name())
-			if err == nil && matched {
-				if info.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
-			}
-		}
-		
-		// For files, check extension and name pattern filters
-		if !info.IsDir() {
-			// Check extension filter
-			if len(filters.Extensions) > 0 {
-				ext := filepath.Ext(path)
-				if ext != "" {
-					ext = ext[1:] // Remove leading dot
-				}
-				
-				found := false
-				for _, e := range filters.Extensions {
-					if e == ext {
-						found = true
-						break
-					}
-				}
-				
-				if !found {
-					return nil
-				}
-			}
-			
-			// Check name pattern filter
-			if filters.NamePattern != "" {
-				matched, err := filepath.Match(filters.NamePattern, info.Name())
-				if err != nil || !matched {
-					return nil
-				}
-			}
-		}
-		
-		fileInfo := &FileInfo{
-			Path:      path,
-			Name:      info.Name(),
-			IsDir:     info.IsDir(),
-			Size:      info.Size(),
-			Extension: filepath.Ext(path),
-			Selected:  false,
-			TokenCount: int(info.Size() / 4), // Rough estimate: 1 token per 4 characters
-		}
-		
-		result = append(result, fileInfo)
-		return nil
-	})
-	
-	return result, err
+	Extensions           []string
+	NamePattern          string
+	IgnorePatterns       []string
+	SubPath              string       // Path relative to the root directory
+	RespectGitignore     bool         // Whether to respect .gitignore files when Ignorer is nil
+	Ignorer              Ignorer      `json:"-"` // Optional ignore engine; takes precedence over RespectGitignore. Not JSON-serializable (interface value); Preset round-trips RespectGitignore/GlobalIgnorePatterns instead, and the walker rebuilds an IgnoreMatcher from those when Ignorer is nil.
+	HiddenFiles          HiddenPolicy // How to treat hidden/system files; defaults to HiddenInclude
+	Tokenizer            Tokenizer    `json:"-"` // Optional tokenizer for accurate counts; nil keeps the size/4 estimate. Not JSON-serializable (interface value); Preset round-trips it by registry name, see Preset.TokenizerName.
+	FollowSymlinks       bool         // Whether to descend into directory symlinks instead of listing them as files
+	GlobalIgnorePatterns []string     // User-editable gitignore-style patterns merged in by IgnoreMatcher when Ignorer is nil and RespectGitignore is set
+	Predicate            Predicate    `json:"-"` // Optional predicate tree (see fileutils/filters); applied in addition to Extensions/NamePattern/IgnorePatterns, nil keeps everything those allow. Not JSON-serializable (interface value) and not yet preset-portable.
 }
 
 // ParseExtensions parses a comma-separated list of extensions
@@ -249,12 +43,12 @@ func ParseExtensions(input string) []string {
 	if input == "" {
 		return nil
 	}
-	
+
 	extensions := strings.Split(input, ",")
 	for i, ext := range extensions {
 		extensions[i] = strings.TrimSpace(ext)
 	}
-	
+
 	return extensions
 }
 
@@ -263,29 +57,29 @@ func ParseIgnorePatterns(input string) []string {
 	if input == "" {
 		return nil
 	}
-	
+
 	patterns := strings.Split(input, ",")
 	for i, pattern := range patterns {
 		patterns[i] = strings.TrimSpace(pattern)
 	}
-	
+
 	return patterns
 }
 
 // GetSelectedFiles returns a list of selected files
 func GetSelectedFiles(files []*FileInfo) []*FileInfo {
 	var selected []*FileInfo
-	
+
 	for _, file := range files {
 		if file.Selected && !file.IsDir {
 			selected = append(selected, file)
 		}
-		
+
 		if len(file.Children) > 0 {
 			selected = append(selected, GetSelectedFiles(file.Children)...)
 		}
 	}
-	
+
 	return selected
 }
 
@@ -293,32 +87,32 @@ func GetSelectedFiles(files []*FileInfo) []*FileInfo {
 func BuildFileTree(files []*FileInfo) []*FileInfo {
 	// Map to store directories
 	dirMap := make(map[string]*FileInfo)
-	
+
 	// Root of the tree
 	var root []*FileInfo
-	
+
 	// First pass: create all directories and initialize their Children slices
 	for _, file := range files {
 		// Initialize Children slice for all files
 		if file.Children == nil {
 			file.Children = []*FileInfo{}
 		}
-		
+
 		if file.IsDir {
 			dirMap[file.Path] = file
 		}
 	}
-	
+
 	// Second pass: add files to their parent directories
 	for _, file := range files {
 		if file.IsDir {
 			// Skip directories for now, we'll handle them in the third pass
 			continue
 		}
-		
+
 		// Get parent directory
 		parentPath := filepath.Dir(file.Path)
-		
+
 		// If parent is in the map, add file to its children
 		if parent, ok := dirMap[parentPath]; ok {
 			parent.Children = append(parent.Children, file)
@@ -327,7 +121,7 @@ func BuildFileTree(files []*FileInfo) []*FileInfo {
 			root = append(root, file)
 		}
 	}
-	
+
 	// Third pass: build directory hierarchy
 	for _, dir := range dirMap {
 		// Skip the current directory if it's already in the root
@@ -341,16 +135,16 @@ func BuildFileTree(files []*FileInfo) []*FileInfo {
 		if alreadyInRoot {
 			continue
 		}
-		
+
 		// Get parent directory
 		parentPath := filepath.Dir(dir.Path)
-		
+
 		// If this is the root directory or parent path is the same as current path
 		if parentPath == dir.Path || parentPath == "." {
 			root = append(root, dir)
 			continue
 		}
-		
+
 		// If parent is in the map, add directory to its children
 		if parent, ok := dirMap[parentPath]; ok && parent.Path != dir.Path {
 			// Avoid circular references
@@ -360,7 +154,7 @@ func BuildFileTree(files []*FileInfo) []*FileInfo {
 			root = append(root, dir)
 		}
 	}
-	
+
 	// If root is empty but we have files, something went wrong
 	// Add all directories to root as a fallback
 	if len(root) == 0 && len(files) > 0 {
@@ -369,23 +163,23 @@ func BuildFileTree(files []*FileInfo) []*FileInfo {
 				root = append(root, file)
 			}
 		}
-		
+
 		// If still empty, add all files
 		if len(root) == 0 {
 			root = files
 		}
 	}
-	
+
 	// Sort root items so directories come first
 	sortFileTreeDirectoriesFirst(root)
-	
+
 	// Sort children of all directories
 	for _, file := range files {
 		if file.IsDir && len(file.Children) > 0 {
 			sortFileTreeDirectoriesFirst(file.Children)
 		}
 	}
-	
+
 	return root
 }
 
@@ -394,7 +188,7 @@ func CalculateDirectoryTokenCount(dir *FileInfo) int {
 	if !dir.IsDir {
 		return dir.TokenCount
 	}
-	
+
 	totalTokens := 0
 	for _, child := range dir.Children {
 		if child.IsDir {
@@ -403,10 +197,10 @@ func CalculateDirectoryTokenCount(dir *FileInfo) int {
 			totalTokens += child.TokenCount
 		}
 	}
-	
+
 	// Update the directory's token count
 	dir.TokenCount = totalTokens
-	
+
 	return totalTokens
 }
 
@@ -0,0 +1,71 @@
+package filters
+
+import "regexp"
+
+// Builder provides a fluent API for assembling a Predicate tree, e.g.
+// filters.New().Ext("go").NotPath("vendor/**").MatchContent("TODO").Build().
+// Every method ANDs its predicate onto the builder; use And/Or/Not
+// directly for more complex combinations.
+type Builder struct {
+	predicates []Predicate
+}
+
+// New starts an empty Builder.
+func New() *Builder {
+	return &Builder{}
+}
+
+// Ext keeps files whose extension matches any of exts (without the dot).
+func (b *Builder) Ext(exts ...string) *Builder {
+	b.predicates = append(b.predicates, &ExtensionPredicate{Extensions: exts})
+	return b
+}
+
+// Path keeps entries whose path matches the extended glob pattern.
+func (b *Builder) Path(pattern string) *Builder {
+	b.predicates = append(b.predicates, NewExtendedGlobPredicate(pattern, false))
+	return b
+}
+
+// NotPath excludes entries whose path matches the extended glob pattern.
+func (b *Builder) NotPath(pattern string) *Builder {
+	b.predicates = append(b.predicates, &NotPredicate{Predicate: NewExtendedGlobPredicate(pattern, false)})
+	return b
+}
+
+// MatchName keeps entries whose basename matches the extended glob pattern.
+func (b *Builder) MatchName(pattern string) *Builder {
+	b.predicates = append(b.predicates, NewExtendedGlobPredicate(pattern, true))
+	return b
+}
+
+// MatchRegex keeps entries whose full path matches re.
+func (b *Builder) MatchRegex(re *regexp.Regexp) *Builder {
+	b.predicates = append(b.predicates, &RegexPredicate{Re: re, Target: RegexFullPath})
+	return b
+}
+
+// MatchContent keeps files containing the literal substring.
+func (b *Builder) MatchContent(literal string) *Builder {
+	b.predicates = append(b.predicates, &ContentGrepPredicate{Literal: []byte(literal)})
+	return b
+}
+
+// SizeRange keeps files whose size falls within [min, max]; max <= 0 means
+// no upper bound.
+func (b *Builder) SizeRange(min, max int64) *Builder {
+	b.predicates = append(b.predicates, &SizeRangePredicate{Min: min, Max: max})
+	return b
+}
+
+// Or ANDs an OrPredicate over the given alternatives onto the builder.
+func (b *Builder) Or(alternatives ...Predicate) *Builder {
+	b.predicates = append(b.predicates, &OrPredicate{Predicates: alternatives})
+	return b
+}
+
+// Build returns the assembled Predicate tree, ANDing together everything
+// added so far.
+func (b *Builder) Build() Predicate {
+	return &AndPredicate{Predicates: b.predicates}
+}
@@ -0,0 +1,121 @@
+package filters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/openprompt/internal/fileutils"
+)
+
+// buildTestTree lays out a small multi-level directory tree under a temp
+// dir, returning its root. Predicate bugs that only show up past the top
+// level (like blocking descent, or a glob predicate never matching a
+// nested path) need at least this much depth to surface.
+func buildTestTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+
+	files := map[string]string{
+		"a.go":            "package root\n// TODO: finish this\n",
+		"sub/b.go":        "package sub\n// TODO: refactor\n",
+		"sub/deeper/c.go": "package deeper\nfunc done() {}\n",
+		"sub/d.txt":       "not go, has TODO too",
+		"vendor/e.go":     "package vendor\n// TODO: ignore me\n",
+	}
+	for rel, contents := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// walkMatches runs Walker.Walk with the given Predicate and returns the
+// matched files' paths relative to root, sorted.
+func walkMatches(t *testing.T, root string, predicate Predicate) []string {
+	t.Helper()
+	w := fileutils.NewWalker(fileutils.FileFilters{Predicate: predicate}, 4)
+
+	var got []string
+	for res := range w.Walk(context.Background(), root) {
+		if res.Err != nil {
+			t.Fatal(res.Err)
+		}
+		if res.Info.IsDir {
+			continue
+		}
+		rel, err := filepath.Rel(root, res.Info.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, filepath.ToSlash(rel))
+	}
+	sort.Strings(got)
+	return got
+}
+
+// TestBuilderContentMatchDescendsIntoSubdirectories is the literal example
+// from the chunk0-5 request body: filters.New().Ext("go").MatchContent("TODO").
+// ContentGrepPredicate returns false for every directory, and
+// SizeRangePredicate does too whenever Min > 0 (a directory's FileInfo.Size
+// is 0), so ANDing either one into a Predicate
+// tree must not stop Walker from recursing past the root — only from
+// emitting the directory nodes themselves.
+func TestBuilderContentMatchDescendsIntoSubdirectories(t *testing.T) {
+	root := buildTestTree(t)
+
+	predicate := New().Ext("go").MatchContent("TODO").Build()
+	got := walkMatches(t, root, predicate)
+
+	want := []string{"a.go", "sub/b.go", "vendor/e.go"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+// TestBuilderSizeRangeDescendsIntoSubdirectories exercises SizeRangePredicate
+// with Min 1, which is false for every directory (their FileInfo.Size is 0).
+func TestBuilderSizeRangeDescendsIntoSubdirectories(t *testing.T) {
+	root := buildTestTree(t)
+
+	predicate := New().SizeRange(1, 0).Build()
+	got := walkMatches(t, root, predicate)
+
+	if len(got) != 5 {
+		t.Fatalf("expected every non-empty file to match regardless of depth, got %v", got)
+	}
+}
+
+// TestBuilderNotPathUnderConcurrentWalk exercises NewExtendedGlobPredicate
+// (via Builder.NotPath, the doc example) with walkMatches' four-worker pool,
+// so the predicate tree is genuinely called from multiple goroutines at
+// once. Run with -race to catch a regression of the unsynchronized lazy
+// compile this replaced.
+func TestBuilderNotPathUnderConcurrentWalk(t *testing.T) {
+	root := buildTestTree(t)
+
+	predicate := New().Ext("go").NotPath("**/vendor/**").Build()
+	got := walkMatches(t, root, predicate)
+
+	want := []string{"a.go", "sub/b.go", "sub/deeper/c.go"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Package filters turns fileutils.FileFilters' hard-coded ext+name matching
+// into a composable Predicate tree, so callers can combine glob, regex,
+// content, size, and mtime checks with AND/OR/NOT instead of being limited
+// to the handful of fields FileFilters exposes directly.
+package filters
+
+import "github.com/openprompt/internal/fileutils"
+
+// Predicate decides whether a single FileInfo should be kept. It's an
+// alias for fileutils.Predicate (rather than a distinct interface of the
+// same shape) so a Predicate tree built here can be dropped straight into
+// FileFilters.Predicate without fileutils needing to import this package.
+type Predicate = fileutils.Predicate
+
+// AndPredicate matches when every child predicate matches. An empty
+// AndPredicate matches everything.
+type AndPredicate struct {
+	Predicates []Predicate
+}
+
+func (p *AndPredicate) Match(info *fileutils.FileInfo) bool {
+	for _, child := range p.Predicates {
+		if !child.Match(info) {
+			return false
+		}
+	}
+	return true
+}
+
+// OrPredicate matches when any child predicate matches. An empty
+// OrPredicate matches nothing.
+type OrPredicate struct {
+	Predicates []Predicate
+}
+
+func (p *OrPredicate) Match(info *fileutils.FileInfo) bool {
+	for _, child := range p.Predicates {
+		if child.Match(info) {
+			return true
+		}
+	}
+	return false
+}
+
+// NotPredicate inverts a single child predicate.
+type NotPredicate struct {
+	Predicate Predicate
+}
+
+func (p *NotPredicate) Match(info *fileutils.FileInfo) bool {
+	return !p.Predicate.Match(info)
+}
@@ -0,0 +1,227 @@
+package filters
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/openprompt/internal/fileutils"
+)
+
+// ExtensionPredicate matches files whose extension (without the leading
+// dot, case-insensitive) is in Extensions. Directories always match so the
+// walker can still descend into them.
+type ExtensionPredicate struct {
+	Extensions []string
+}
+
+func (p *ExtensionPredicate) Match(info *fileutils.FileInfo) bool {
+	if info.IsDir {
+		return true
+	}
+	ext := strings.TrimPrefix(info.Extension, ".")
+	for _, e := range p.Extensions {
+		if strings.EqualFold(e, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// GlobPredicate matches a path.Match-style pattern, either against the
+// full path or just the basename.
+type GlobPredicate struct {
+	Pattern       string
+	MatchBasename bool
+}
+
+func (p *GlobPredicate) Match(info *fileutils.FileInfo) bool {
+	target := filepath.ToSlash(info.Path)
+	if p.MatchBasename {
+		target = info.Name
+	}
+	matched, _ := filepath.Match(p.Pattern, target)
+	return matched
+}
+
+// ExtendedGlobPredicate matches a glob pattern extended with "**" segments
+// (matching any number of path components) and "{a,b,c}" brace expansion,
+// resolved locally without spawning a shell.
+type ExtendedGlobPredicate struct {
+	Pattern       string
+	MatchBasename bool
+
+	compiled []*regexp.Regexp // one per brace-expanded alternative, set at construction
+}
+
+// NewExtendedGlobPredicate builds an ExtendedGlobPredicate with its regexps
+// compiled up front. Match is called concurrently by Walker's worker pool
+// (every worker shares one FileFilters.Predicate tree), so compiling here
+// rather than lazily on first Match avoids an unsynchronized read/write
+// race on p.compiled; always construct through this rather than the struct
+// literal directly.
+func NewExtendedGlobPredicate(pattern string, matchBasename bool) *ExtendedGlobPredicate {
+	p := &ExtendedGlobPredicate{Pattern: pattern, MatchBasename: matchBasename}
+	for _, alt := range expandBraces(pattern) {
+		p.compiled = append(p.compiled, extGlobToRegexp(alt))
+	}
+	return p
+}
+
+func (p *ExtendedGlobPredicate) Match(info *fileutils.FileInfo) bool {
+	target := filepath.ToSlash(info.Path)
+	if p.MatchBasename {
+		target = info.Name
+	}
+
+	for _, re := range p.compiled {
+		if re.MatchString(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandBraces resolves "{a,b,c}" groups in pattern into their cartesian
+// combinations, e.g. "*.{go,ts}" -> ["*.go", "*.ts"]. Nested braces aren't
+// supported, matching the common shell-brace-expansion use case this is
+// meant to cover.
+func expandBraces(pattern string) []string {
+	start := strings.IndexByte(pattern, '{')
+	if start == -1 {
+		return []string{pattern}
+	}
+	relEnd := strings.IndexByte(pattern[start:], '}')
+	if relEnd == -1 {
+		return []string{pattern}
+	}
+	end := start + relEnd
+
+	prefix, suffix := pattern[:start], pattern[end+1:]
+	options := strings.Split(pattern[start+1:end], ",")
+
+	var results []string
+	for _, opt := range options {
+		results = append(results, expandBraces(prefix+opt+suffix)...)
+	}
+	return results
+}
+
+// extGlobToRegexp translates an extended glob (supporting "**", "*", "?")
+// into an anchored, slash-aware regexp.
+func extGlobToRegexp(pattern string) *regexp.Regexp {
+	var out strings.Builder
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '*' && i+1 < len(runes) && runes[i+1] == '*':
+			out.WriteString(".*")
+			i++
+		case c == '*':
+			out.WriteString("[^/]*")
+		case c == '?':
+			out.WriteString("[^/]")
+		default:
+			out.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re, err := regexp.Compile("^" + out.String() + "$")
+	if err != nil {
+		// Fall back to a pattern that matches nothing rather than panicking
+		// on a malformed user-supplied glob.
+		return regexp.MustCompile(`$^`)
+	}
+	return re
+}
+
+// RegexTarget selects what portion of a path a RegexPredicate matches
+// against.
+type RegexTarget int
+
+const (
+	RegexFullPath RegexTarget = iota
+	RegexBasename
+)
+
+// RegexPredicate matches an arbitrary regular expression against the
+// basename or full path.
+type RegexPredicate struct {
+	Re     *regexp.Regexp
+	Target RegexTarget
+}
+
+func (p *RegexPredicate) Match(info *fileutils.FileInfo) bool {
+	target := filepath.ToSlash(info.Path)
+	if p.Target == RegexBasename {
+		target = info.Name
+	}
+	return p.Re.MatchString(target)
+}
+
+// DefaultMaxGrepSize caps how large a file ContentGrepPredicate will read,
+// so a single huge binary can't stall a scan.
+const DefaultMaxGrepSize = 10 * 1024 * 1024 // 10MB
+
+// ContentGrepPredicate matches files containing a literal byte sequence,
+// using bytes.Contains' optimized substring search. Files over MaxFileSize
+// (or DefaultMaxGrepSize if unset) are skipped rather than read in full.
+type ContentGrepPredicate struct {
+	Literal     []byte
+	MaxFileSize int64
+}
+
+func (p *ContentGrepPredicate) Match(info *fileutils.FileInfo) bool {
+	if info.IsDir {
+		return false
+	}
+
+	limit := p.MaxFileSize
+	if limit <= 0 {
+		limit = DefaultMaxGrepSize
+	}
+	if info.Size > limit {
+		return false
+	}
+
+	data, err := os.ReadFile(info.Path)
+	if err != nil {
+		return false
+	}
+	return bytes.Contains(data, p.Literal)
+}
+
+// SizeRangePredicate matches files whose size falls within [Min, Max].
+// Max <= 0 means no upper bound.
+type SizeRangePredicate struct {
+	Min, Max int64
+}
+
+func (p *SizeRangePredicate) Match(info *fileutils.FileInfo) bool {
+	if info.Size < p.Min {
+		return false
+	}
+	if p.Max > 0 && info.Size > p.Max {
+		return false
+	}
+	return true
+}
+
+// MTimeRangePredicate matches files last modified within [After, Before].
+// A zero time.Time on either bound leaves that side unconstrained.
+type MTimeRangePredicate struct {
+	After, Before time.Time
+}
+
+func (p *MTimeRangePredicate) Match(info *fileutils.FileInfo) bool {
+	if !p.After.IsZero() && info.ModTime.Before(p.After) {
+		return false
+	}
+	if !p.Before.IsZero() && info.ModTime.After(p.Before) {
+		return false
+	}
+	return true
+}
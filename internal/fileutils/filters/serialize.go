@@ -0,0 +1,170 @@
+package filters
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// spec is the tagged-union on-disk representation of a Predicate, since
+// JSON/YAML can't round-trip an interface value on its own.
+type spec struct {
+	Type     string `json:"type" yaml:"type"`
+	Children []spec `json:"children,omitempty" yaml:"children,omitempty"` // and, or
+	Child    *spec  `json:"child,omitempty" yaml:"child,omitempty"`       // not
+
+	Pattern    string   `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	Basename   bool     `json:"basename,omitempty" yaml:"basename,omitempty"`
+	Extensions []string `json:"extensions,omitempty" yaml:"extensions,omitempty"`
+	Literal    string   `json:"literal,omitempty" yaml:"literal,omitempty"`
+	MaxBytes   int64    `json:"maxBytes,omitempty" yaml:"maxBytes,omitempty"`
+	Min        int64    `json:"min,omitempty" yaml:"min,omitempty"`
+	Max        int64    `json:"max,omitempty" yaml:"max,omitempty"`
+}
+
+// toSpec converts a Predicate tree into its serializable form.
+func toSpec(p Predicate) (spec, error) {
+	switch v := p.(type) {
+	case *AndPredicate:
+		s := spec{Type: "and"}
+		for _, c := range v.Predicates {
+			cs, err := toSpec(c)
+			if err != nil {
+				return spec{}, err
+			}
+			s.Children = append(s.Children, cs)
+		}
+		return s, nil
+	case *OrPredicate:
+		s := spec{Type: "or"}
+		for _, c := range v.Predicates {
+			cs, err := toSpec(c)
+			if err != nil {
+				return spec{}, err
+			}
+			s.Children = append(s.Children, cs)
+		}
+		return s, nil
+	case *NotPredicate:
+		cs, err := toSpec(v.Predicate)
+		if err != nil {
+			return spec{}, err
+		}
+		return spec{Type: "not", Child: &cs}, nil
+	case *ExtensionPredicate:
+		return spec{Type: "ext", Extensions: v.Extensions}, nil
+	case *GlobPredicate:
+		return spec{Type: "glob", Pattern: v.Pattern, Basename: v.MatchBasename}, nil
+	case *ExtendedGlobPredicate:
+		return spec{Type: "extglob", Pattern: v.Pattern, Basename: v.MatchBasename}, nil
+	case *RegexPredicate:
+		return spec{Type: "regex", Pattern: v.Re.String(), Basename: v.Target == RegexBasename}, nil
+	case *ContentGrepPredicate:
+		return spec{Type: "contentGrep", Literal: string(v.Literal), MaxBytes: v.MaxFileSize}, nil
+	case *SizeRangePredicate:
+		return spec{Type: "sizeRange", Min: v.Min, Max: v.Max}, nil
+	default:
+		return spec{}, fmt.Errorf("filters: no serializer registered for %T", p)
+	}
+}
+
+// fromSpec reconstructs a Predicate tree from its serialized form.
+func fromSpec(s spec) (Predicate, error) {
+	switch s.Type {
+	case "and":
+		children, err := fromSpecs(s.Children)
+		if err != nil {
+			return nil, err
+		}
+		return &AndPredicate{Predicates: children}, nil
+	case "or":
+		children, err := fromSpecs(s.Children)
+		if err != nil {
+			return nil, err
+		}
+		return &OrPredicate{Predicates: children}, nil
+	case "not":
+		if s.Child == nil {
+			return nil, fmt.Errorf("filters: \"not\" spec is missing its child")
+		}
+		child, err := fromSpec(*s.Child)
+		if err != nil {
+			return nil, err
+		}
+		return &NotPredicate{Predicate: child}, nil
+	case "ext":
+		return &ExtensionPredicate{Extensions: s.Extensions}, nil
+	case "glob":
+		return &GlobPredicate{Pattern: s.Pattern, MatchBasename: s.Basename}, nil
+	case "extglob":
+		return NewExtendedGlobPredicate(s.Pattern, s.Basename), nil
+	case "regex":
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		target := RegexFullPath
+		if s.Basename {
+			target = RegexBasename
+		}
+		return &RegexPredicate{Re: re, Target: target}, nil
+	case "contentGrep":
+		return &ContentGrepPredicate{Literal: []byte(s.Literal), MaxFileSize: s.MaxBytes}, nil
+	case "sizeRange":
+		return &SizeRangePredicate{Min: s.Min, Max: s.Max}, nil
+	default:
+		return nil, fmt.Errorf("filters: unknown predicate type %q", s.Type)
+	}
+}
+
+func fromSpecs(specs []spec) ([]Predicate, error) {
+	predicates := make([]Predicate, 0, len(specs))
+	for _, cs := range specs {
+		p, err := fromSpec(cs)
+		if err != nil {
+			return nil, err
+		}
+		predicates = append(predicates, p)
+	}
+	return predicates, nil
+}
+
+// MarshalJSON serializes a Predicate tree to JSON.
+func MarshalJSON(p Predicate) ([]byte, error) {
+	s, err := toSpec(p)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// UnmarshalJSON deserializes a Predicate tree previously written by
+// MarshalJSON.
+func UnmarshalJSON(data []byte) (Predicate, error) {
+	var s spec
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return fromSpec(s)
+}
+
+// MarshalYAML serializes a Predicate tree to YAML.
+func MarshalYAML(p Predicate) ([]byte, error) {
+	s, err := toSpec(p)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(s)
+}
+
+// UnmarshalYAML deserializes a Predicate tree previously written by
+// MarshalYAML.
+func UnmarshalYAML(data []byte) (Predicate, error) {
+	var s spec
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return fromSpec(s)
+}
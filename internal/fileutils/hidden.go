@@ -0,0 +1,40 @@
+package fileutils
+
+import "strings"
+
+// HiddenPolicy controls how ListFiles treats hidden/system files.
+type HiddenPolicy int
+
+const (
+	// HiddenInclude lists hidden files alongside everything else.
+	HiddenInclude HiddenPolicy = iota
+	// HiddenExclude skips any file or directory considered hidden on the
+	// current OS (dot-prefixed on Unix, FILE_ATTRIBUTE_HIDDEN/SYSTEM on
+	// Windows).
+	HiddenExclude
+	// HiddenOnlyDotfiles skips dot-prefixed entries only, ignoring any
+	// OS-level hidden/system attribute. Mainly useful on Windows, where a
+	// dotfile isn't otherwise flagged hidden by the file system.
+	HiddenOnlyDotfiles
+)
+
+// isDotfile reports whether name is hidden by the leading-"." convention
+// shared by every platform.
+func isDotfile(name string) bool {
+	return strings.HasPrefix(name, ".") && name != "." && name != ".."
+}
+
+// shouldSkipHidden reports whether the entry at path (with base name name)
+// should be skipped under the given policy.
+func shouldSkipHidden(policy HiddenPolicy, path, name string) bool {
+	switch policy {
+	case HiddenInclude:
+		return false
+	case HiddenOnlyDotfiles:
+		return isDotfile(name)
+	case HiddenExclude:
+		return isDotfile(name) || isHiddenOnOS(path)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,303 @@
+package fileutils
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Ignorer decides whether a path should be excluded from a file listing.
+// FileFilters accepts any Ignorer implementation via injection, so callers
+// can plug in git semantics, a Docker-style ignore file, or a plain glob
+// list without ListFiles needing to know which.
+type Ignorer interface {
+	// IgnoreFile reports whether the file at path (absolute) should be skipped.
+	IgnoreFile(path string) bool
+	// IgnoreDir reports whether the directory at path (absolute), and
+	// everything beneath it, should be skipped.
+	IgnoreDir(path string) bool
+}
+
+// ignoreRule is a single compiled line from a .gitignore-style file.
+type ignoreRule struct {
+	negate  bool
+	dirOnly bool
+	re      *regexp.Regexp
+	pattern string // the original line, kept for IgnoreMatch.Rule display
+}
+
+func (r *ignoreRule) matches(relPath string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	return r.re.MatchString(relPath)
+}
+
+// compileIgnoreLine parses a single non-blank, non-comment gitignore line
+// into a reusable ignoreRule.
+func compileIgnoreLine(line string) (*ignoreRule, error) {
+	rule := &ignoreRule{}
+
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		// A leading backslash escapes a literal "!" or "#".
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+
+	anchored := strings.HasPrefix(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	// Per the gitignore spec, any pattern containing a non-trailing "/"
+	// is anchored to the directory that declared it, not just patterns
+	// with an explicit leading "/".
+	anchored = anchored || strings.Contains(line, "/")
+
+	pattern := globToRegexpFragment(line)
+	if !anchored {
+		pattern = "(?:.*/)?" + pattern
+	}
+
+	re, err := regexp.Compile("^" + pattern + "$")
+	if err != nil {
+		return nil, err
+	}
+	rule.re = re
+	return rule, nil
+}
+
+// globToRegexpFragment translates a gitignore glob into an equivalent
+// regexp fragment, handling "**", "*", "?" and "[...]" character classes.
+func globToRegexpFragment(pattern string) string {
+	var out strings.Builder
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '*' && i+1 < len(runes) && runes[i+1] == '*':
+			// "**/" matches zero or more directories (including none),
+			// a trailing "/**" matches everything inside, and a bare
+			// "**" falls back to matching across directory boundaries.
+			if i+2 < len(runes) && runes[i+2] == '/' {
+				out.WriteString("(?:.*/)?")
+				i += 2
+			} else {
+				out.WriteString(".*")
+				i++
+			}
+		case c == '*':
+			out.WriteString("[^/]*")
+		case c == '?':
+			out.WriteString("[^/]")
+		case c == '[':
+			j := i + 1
+			for j < len(runes) && runes[j] != ']' {
+				j++
+			}
+			if j < len(runes) {
+				out.WriteString("[" + string(runes[i+1:j]) + "]")
+				i = j
+			} else {
+				out.WriteString(regexp.QuoteMeta(string(c)))
+			}
+		default:
+			out.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return out.String()
+}
+
+// loadIgnoreRules reads a gitignore-style file, skipping blank lines and
+// comments, and returns the compiled rules in file order.
+func loadIgnoreRules(path string) ([]*ignoreRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []*ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := compileIgnoreLine(line)
+		if err != nil {
+			// Skip unparsable lines rather than failing the whole file.
+			continue
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// GitIgnorer implements Ignorer with git's own layering rules: the global
+// excludes (core.excludesFile, .git/info/exclude) apply first, then each
+// directory's .gitignore from the repository root down to the file's own
+// directory, with nearer files evaluated last so their rules win ties.
+// Because IgnoreDir causes the walker to skip a directory entirely, files
+// beneath an excluded directory are never visited, so a negation pattern
+// inside them is correctly a no-op, matching git's own behavior.
+type GitIgnorer struct {
+	root   string
+	global []*ignoreRule
+	perDir map[string][]*ignoreRule
+
+	// userGlobal is populated by NewIgnoreMatcher from a user-editable
+	// pattern list (see Preferences.GlobalIgnorePatterns), evaluated at the
+	// same lowest-priority point as global so a repo's own .gitignore can
+	// still negate it.
+	userGlobal []*ignoreRule
+}
+
+// NewGitIgnorer builds an Ignorer rooted at root, eagerly loading
+// .git/info/exclude and the user's core.excludesFile. Per-directory
+// .gitignore files are loaded lazily as the tree is walked.
+func NewGitIgnorer(root string) (*GitIgnorer, error) {
+	g := &GitIgnorer{
+		root:   root,
+		perDir: make(map[string][]*ignoreRule),
+	}
+
+	if rules, err := loadIgnoreRules(filepath.Join(root, ".git", "info", "exclude")); err == nil {
+		g.global = append(g.global, rules...)
+	}
+	if excludesFile := globalExcludesFile(); excludesFile != "" {
+		if rules, err := loadIgnoreRules(excludesFile); err == nil {
+			g.global = append(g.global, rules...)
+		}
+	}
+
+	return g, nil
+}
+
+// globalExcludesFile resolves the user's core.excludesFile via `git config`,
+// returning "" if git isn't available or the setting isn't configured.
+func globalExcludesFile() string {
+	out, err := exec.Command("git", "config", "--global", "core.excludesFile").Output()
+	if err != nil {
+		return ""
+	}
+	path := strings.TrimSpace(string(out))
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
+
+// rulesForDir returns the rules declared directly by dir's own .gitignore,
+// loading and caching them on first use.
+func (g *GitIgnorer) rulesForDir(dir string) []*ignoreRule {
+	if rules, ok := g.perDir[dir]; ok {
+		return rules
+	}
+	rules, err := loadIgnoreRules(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		rules = nil
+	}
+	g.perDir[dir] = rules
+	return rules
+}
+
+// chain returns the directories from the repository root down to dir,
+// in evaluation order (farthest first, dir itself last).
+func (g *GitIgnorer) chain(dir string) []string {
+	rel, err := filepath.Rel(g.root, dir)
+	if err != nil || rel == "." {
+		return []string{g.root}
+	}
+
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	dirs := make([]string, 0, len(parts)+1)
+	cur := g.root
+	dirs = append(dirs, cur)
+	for _, part := range parts {
+		cur = filepath.Join(cur, part)
+		dirs = append(dirs, cur)
+	}
+	return dirs
+}
+
+// IgnoreMatch reports an Ignorer's decision for a path plus, when the
+// Ignorer can explain itself (GitIgnorer and IgnoreMatcher both can), which
+// specific rule caused it — used for the file tree's "why is this greyed
+// out" tooltip.
+type IgnoreMatch struct {
+	Ignored bool
+	Rule    string // the matching pattern as written, e.g. "node_modules/"
+	Source  string // where Rule came from, e.g. ".gitignore" or "global ignore list"
+}
+
+// match is the shared evaluation used by IgnoreFile, IgnoreDir, MatchFile
+// and MatchDir.
+func (g *GitIgnorer) match(path string, isDir bool) IgnoreMatch {
+	// The rules that apply to path, whether a file or a directory, are the
+	// ones declared in its parent's .gitignore (and the parent's ancestors).
+	dir := filepath.Dir(path)
+
+	var result IgnoreMatch
+	evaluate := func(source, declaringDir string, rules []*ignoreRule) {
+		if len(rules) == 0 {
+			return
+		}
+		relPath, err := filepath.Rel(declaringDir, path)
+		if err != nil {
+			return
+		}
+		relPath = filepath.ToSlash(relPath)
+		for _, rule := range rules {
+			if rule.matches(relPath, isDir) {
+				result = IgnoreMatch{Ignored: !rule.negate, Rule: rule.pattern, Source: source}
+			}
+		}
+	}
+
+	evaluate("global ignore list", g.root, g.userGlobal)
+	evaluate("git exclude", g.root, g.global)
+	for _, d := range g.chain(dir) {
+		source := ".gitignore"
+		if rel, err := filepath.Rel(g.root, d); err == nil && rel != "." {
+			source = filepath.ToSlash(filepath.Join(rel, ".gitignore"))
+		}
+		evaluate(source, d, g.rulesForDir(d))
+	}
+
+	return result
+}
+
+// IgnoreFile reports whether path should be excluded from listings.
+func (g *GitIgnorer) IgnoreFile(path string) bool {
+	return g.match(path, false).Ignored
+}
+
+// IgnoreDir reports whether path, and everything beneath it, should be
+// excluded from listings.
+func (g *GitIgnorer) IgnoreDir(path string) bool {
+	return g.match(path, true).Ignored
+}
+
+// MatchFile is IgnoreFile plus which rule caused the decision.
+func (g *GitIgnorer) MatchFile(path string) IgnoreMatch {
+	return g.match(path, false)
+}
+
+// MatchDir is IgnoreDir plus which rule caused the decision.
+func (g *GitIgnorer) MatchDir(path string) IgnoreMatch {
+	return g.match(path, true)
+}
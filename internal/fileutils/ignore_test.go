@@ -0,0 +1,157 @@
+package fileutils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFile creates path (and its parent directories) with contents, failing
+// the test on any error.
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGitIgnorerMatch(t *testing.T) {
+	root := t.TempDir()
+
+	writeFile(t, filepath.Join(root, ".gitignore"), strings.Join([]string{
+		"*.log",
+		"/build",
+		"temp/",
+		"vendor/**/testdata",
+		"secret.txt",
+		"!secret.txt",
+	}, "\n"))
+	writeFile(t, filepath.Join(root, "src", ".gitignore"), "*.tmp\n")
+
+	tests := []struct {
+		name    string
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{
+			name:    "unanchored glob matches at any depth",
+			path:    filepath.Join(root, "src", "debug.log"),
+			ignored: true,
+		},
+		{
+			name:    "anchored pattern only matches at the declaring directory",
+			path:    filepath.Join(root, "build"),
+			isDir:   true,
+			ignored: true,
+		},
+		{
+			name:    "anchored pattern does not match a nested directory of the same name",
+			path:    filepath.Join(root, "src", "build"),
+			isDir:   true,
+			ignored: false,
+		},
+		{
+			name:    "dirOnly pattern does not match a file with the same name",
+			path:    filepath.Join(root, "temp"),
+			isDir:   false,
+			ignored: false,
+		},
+		{
+			name:    "dirOnly pattern matches the directory",
+			path:    filepath.Join(root, "temp"),
+			isDir:   true,
+			ignored: true,
+		},
+		{
+			name:    "** matches across directory boundaries",
+			path:    filepath.Join(root, "vendor", "pkg", "testdata"),
+			isDir:   true,
+			ignored: true,
+		},
+		{
+			name:    "negation after exclude in the same file re-includes the path",
+			path:    filepath.Join(root, "secret.txt"),
+			ignored: false,
+		},
+		{
+			name:    "a nested .gitignore's rules apply only under its own directory",
+			path:    filepath.Join(root, "scratch.tmp"),
+			ignored: false,
+		},
+		{
+			name:    "a nested .gitignore's rules apply under its own directory",
+			path:    filepath.Join(root, "src", "scratch.tmp"),
+			ignored: true,
+		},
+	}
+
+	g, err := NewGitIgnorer(root)
+	if err != nil {
+		t.Fatalf("NewGitIgnorer: %v", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got bool
+			if tt.isDir {
+				got = g.IgnoreDir(tt.path)
+			} else {
+				got = g.IgnoreFile(tt.path)
+			}
+			if got != tt.ignored {
+				t.Errorf("ignored = %v, want %v", got, tt.ignored)
+			}
+		})
+	}
+}
+
+// TestGitIgnorerUserGlobalPrecedence verifies that a user-editable global
+// pattern (see IgnoreMatcher) is the lowest-priority layer: a repo's own
+// .gitignore can negate it, but in the absence of any repo rule it still
+// excludes the path tree-wide.
+func TestGitIgnorerUserGlobalPrecedence(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".gitignore"), "!kept/node_modules\n")
+	writeFile(t, filepath.Join(root, "kept", ".gitignore"), "")
+
+	m, err := NewIgnoreMatcher(root, []string{"node_modules/"})
+	if err != nil {
+		t.Fatalf("NewIgnoreMatcher: %v", err)
+	}
+
+	if !m.IgnoreDir(filepath.Join(root, "node_modules")) {
+		t.Error("node_modules should be ignored by the global pattern with no repo rule to override it")
+	}
+	if m.IgnoreDir(filepath.Join(root, "kept", "node_modules")) {
+		t.Error("kept/node_modules should be re-included by the repo's own negation, which outranks the global list")
+	}
+}
+
+// TestIgnoreMatchExplainsTheRule verifies MatchFile/MatchDir report which
+// rule and source caused the decision, used to drive the file tree's
+// greyed-out tooltip.
+func TestIgnoreMatchExplainsTheRule(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".gitignore"), "*.log\n")
+
+	g, err := NewGitIgnorer(root)
+	if err != nil {
+		t.Fatalf("NewGitIgnorer: %v", err)
+	}
+
+	match := g.MatchFile(filepath.Join(root, "debug.log"))
+	if !match.Ignored {
+		t.Fatal("expected debug.log to be ignored")
+	}
+	if match.Rule != "*.log" {
+		t.Errorf("Rule = %q, want %q", match.Rule, "*.log")
+	}
+	if match.Source != ".gitignore" {
+		t.Errorf("Source = %q, want %q", match.Source, ".gitignore")
+	}
+}
@@ -0,0 +1,58 @@
+package fileutils
+
+// DefaultGlobalIgnorePatterns are the global ignore rules IgnoreMatcher
+// applies even in a tree with no .gitignore at all — the patterns most
+// projects end up excluding by hand: dependency directories, VCS metadata,
+// minified bundles, and common binary/image formats that rarely belong in
+// an LLM prompt. Preferences.GlobalIgnorePatterns starts out as a copy of
+// this list, and the user can edit it from there.
+var DefaultGlobalIgnorePatterns = []string{
+	"node_modules/",
+	".git/",
+	"*.min.js",
+	"*.png", "*.jpg", "*.jpeg", "*.gif", "*.ico", "*.bmp", "*.webp",
+	"*.exe", "*.dll", "*.so", "*.dylib", "*.bin", "*.o", "*.a",
+}
+
+// ruleMatcher is implemented by Ignorers that can explain which specific
+// rule caused a decision. Walker uses it, when available, to attach that
+// reason to FileInfo.IgnoreRule for the file tree's tooltip; a plain
+// Ignorer that doesn't implement it still works, it just can't explain
+// itself.
+type ruleMatcher interface {
+	MatchFile(path string) IgnoreMatch
+	MatchDir(path string) IgnoreMatch
+}
+
+// IgnoreMatcher is the Ignorer GenerateXML's generation path and the file
+// tree both rely on: a GitIgnorer's nested .gitignore / .git/info/exclude
+// resolution (deepest match wins, negations re-include), with a
+// user-editable global ignore list layered in at the lowest priority, so a
+// rule like "node_modules/" excludes a path tree-wide even when nothing in
+// the tree has a .gitignore at all, while a repo's own negation pattern can
+// still re-include anything the global list would otherwise exclude.
+type IgnoreMatcher struct {
+	*GitIgnorer
+}
+
+// NewIgnoreMatcher builds an IgnoreMatcher rooted at root the same way
+// NewGitIgnorer does, compiling globalPatterns as the extra lowest-priority
+// layer. Unparsable patterns are skipped rather than failing construction,
+// matching loadIgnoreRules' own behavior for a malformed .gitignore line.
+func NewIgnoreMatcher(root string, globalPatterns []string) (*IgnoreMatcher, error) {
+	git, err := NewGitIgnorer(root)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range globalPatterns {
+		rule, err := compileIgnoreLine(p)
+		if err != nil {
+			continue
+		}
+		rule.pattern = p
+		git.userGlobal = append(git.userGlobal, rule)
+	}
+
+	return &IgnoreMatcher{GitIgnorer: git}, nil
+}
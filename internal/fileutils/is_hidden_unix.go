@@ -0,0 +1,11 @@
+//go:build !windows
+
+package fileutils
+
+// isHiddenOnOS reports whether path carries an OS-level hidden attribute.
+// Unix file systems have no such attribute beyond the leading-"." naming
+// convention, which is already handled by isDotfile, so there's nothing
+// further to check here.
+func isHiddenOnOS(path string) bool {
+	return false
+}
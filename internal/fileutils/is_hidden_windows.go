@@ -0,0 +1,21 @@
+//go:build windows
+
+package fileutils
+
+import "syscall"
+
+// isHiddenOnOS reports whether path carries the Windows FILE_ATTRIBUTE_HIDDEN
+// or FILE_ATTRIBUTE_SYSTEM bit, which dot-prefix naming alone wouldn't catch.
+func isHiddenOnOS(path string) bool {
+	ptr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return false
+	}
+
+	attrs, err := syscall.GetFileAttributes(ptr)
+	if err != nil {
+		return false
+	}
+
+	return attrs&(syscall.FILE_ATTRIBUTE_HIDDEN|syscall.FILE_ATTRIBUTE_SYSTEM) != 0
+}
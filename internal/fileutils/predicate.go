@@ -0,0 +1,12 @@
+package fileutils
+
+// Predicate decides whether a single FileInfo should be kept. It's the
+// extension point fileutils/filters' composable Predicate tree
+// (AndPredicate, OrPredicate, glob/regex/content/size/mtime leaves) plugs
+// into via FileFilters.Predicate, the same way Ignorer and Tokenizer are
+// injected — fileutils doesn't import fileutils/filters (that would be a
+// cycle, since filters already imports fileutils for FileInfo), so any
+// type whose Match(*FileInfo) bool satisfies this interface structurally.
+type Predicate interface {
+	Match(info *FileInfo) bool
+}
@@ -0,0 +1,151 @@
+package fileutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// PresetSchemaVersion is bumped whenever Preset's on-disk shape changes, so
+// LoadFromFile can detect and migrate older files instead of misreading them.
+const PresetSchemaVersion = 1
+
+// Preset is a shareable snapshot of a file tree session: the filters used to
+// populate it, which directories were expanded, and which files were
+// selected. Saving one to a `.repoprompt.json` alongside a project lets a
+// team commit the same context bundle everyone prompts an LLM with.
+type Preset struct {
+	SchemaVersion int `json:"schemaVersion"`
+
+	// RootDir is stored with environment variables left uninterpolated
+	// (e.g. "$HOME/code/myproject") so the preset is portable across
+	// machines; ExpandRootDir resolves it for the current one.
+	RootDir string `json:"rootDir"`
+
+	Filters      FileFilters `json:"filters"`
+	ExpandedDirs []string    `json:"expandedDirs"`
+
+	// Selected lists file paths relative to RootDir, not absolute paths,
+	// so the preset still makes sense after a clone to a different path.
+	Selected []string `json:"selected"`
+
+	// TokenizerName is Filters.Tokenizer's registry name (see
+	// DefaultTokenizerRegistry), stored separately because Tokenizer is an
+	// interface value and can't round-trip through JSON on its own.
+	// SaveToFile populates it from Filters.Tokenizer; LoadFromFile resolves
+	// it back through the registry and sets Filters.Tokenizer. Empty means
+	// the heuristic size/4 estimate, matching a nil Tokenizer.
+	TokenizerName string `json:"tokenizerName,omitempty"`
+}
+
+// ExpandRootDir returns p.RootDir with any $VAR or ${VAR} references
+// resolved against the current environment.
+func (p *Preset) ExpandRootDir() string {
+	return os.ExpandEnv(p.RootDir)
+}
+
+// SaveToFile writes the preset to path as indented JSON.
+func (p *Preset) SaveToFile(path string) error {
+	p.SchemaVersion = PresetSchemaVersion
+
+	if p.Filters.Tokenizer != nil {
+		p.TokenizerName = p.Filters.Tokenizer.Name()
+	}
+
+	// Keep the selection list stable across saves so diffs in version
+	// control are readable.
+	sort.Strings(p.Selected)
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("fileutils: marshaling preset: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadFromFile reads a preset previously written by SaveToFile.
+func LoadFromFile(path string) (*Preset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("fileutils: reading preset: %w", err)
+	}
+
+	var p Preset
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("fileutils: parsing preset: %w", err)
+	}
+
+	if p.SchemaVersion > PresetSchemaVersion {
+		return nil, fmt.Errorf("fileutils: preset schema version %d is newer than this build supports (%d)", p.SchemaVersion, PresetSchemaVersion)
+	}
+	// SchemaVersion 0 means the field predates this version field; there's
+	// nothing to migrate yet, so it's accepted as-is.
+
+	if p.TokenizerName != "" {
+		tok, err := DefaultTokenizerRegistry.Get(p.TokenizerName)
+		if err != nil {
+			return nil, fmt.Errorf("fileutils: preset tokenizer: %w", err)
+		}
+		p.Filters.Tokenizer = tok
+	}
+
+	return &p, nil
+}
+
+// ApplyResult reports what happened when a Preset's selection was reapplied
+// to a freshly loaded file tree, so the caller can tell the user about any
+// paths that no longer exist.
+type ApplyResult struct {
+	Applied []string // relative paths that were found and selected
+	Missing []string // relative paths from the preset that no longer exist
+}
+
+// Apply walks files (as produced by BuildFileTree) and selects every entry
+// whose path, relative to rootDir, appears in p.Selected. It reports any
+// selected paths that weren't found so the caller can surface the diff.
+func (p *Preset) Apply(files []*FileInfo, rootDir string) ApplyResult {
+	want := make(map[string]bool, len(p.Selected))
+	for _, rel := range p.Selected {
+		want[rel] = true
+	}
+
+	found := make(map[string]bool, len(p.Selected))
+	var walk func([]*FileInfo)
+	walk = func(nodes []*FileInfo) {
+		for _, f := range nodes {
+			rel := relativeTo(rootDir, f.Path)
+			if want[rel] {
+				f.Selected = true
+				found[rel] = true
+			}
+			if f.IsDir {
+				walk(f.Children)
+			}
+		}
+	}
+	walk(files)
+
+	result := ApplyResult{}
+	for _, rel := range p.Selected {
+		if found[rel] {
+			result.Applied = append(result.Applied, rel)
+		} else {
+			result.Missing = append(result.Missing, rel)
+		}
+	}
+	sort.Strings(result.Applied)
+	sort.Strings(result.Missing)
+	return result
+}
+
+// relativeTo returns path relative to root using forward slashes, or path
+// itself (slash-normalized) if it isn't under root.
+func relativeTo(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
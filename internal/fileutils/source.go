@@ -0,0 +1,301 @@
+package fileutils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Source abstracts where a file tree and its content come from, so
+// generation can ingest a plain directory or an archive
+// (.zip/.tar/.tar.gz/.tar.bz2) through the same interface: the extension is
+// dispatched on once, at NewSource, rather than every downstream reader
+// needing to know whether it's looking at a real path or an archive entry.
+type Source interface {
+	// Open returns the content at path — a path is whatever Walk emitted as
+	// a FileInfo.Path for this Source: a filesystem path for OSSource, an
+	// archive-internal entry name for an archive Source.
+	Open(path string) (io.ReadCloser, error)
+	// Walk streams every file and directory under root the same way
+	// Walker.Walk does.
+	Walk(ctx context.Context, root string, filters FileFilters) <-chan WalkResult
+}
+
+// osSource is the Source backing a plain directory on disk; it's just a
+// thin adapter over the existing Walker and os.Open.
+type osSource struct{}
+
+// OSSource is the shared Source for real directories. It has no state, so
+// one value covers every directory — callers compare against it to decide
+// whether a fast, cache-backed read path applies.
+var OSSource Source = osSource{}
+
+func (osSource) Open(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+func (osSource) Walk(ctx context.Context, root string, filters FileFilters) <-chan WalkResult {
+	return NewWalker(filters, 0).Walk(ctx, root)
+}
+
+// archiveExtensions lists the suffixes NewSource recognizes as archives;
+// IsArchivePath compares them case-insensitively, and their order doesn't
+// affect the result.
+var archiveExtensions = []string{".tar.gz", ".tar.bz2", ".tgz", ".tar", ".zip"}
+
+// IsArchivePath reports whether path names a file NewSource would open as
+// an archive rather than a directory.
+func IsArchivePath(path string) bool {
+	lower := strings.ToLower(path)
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSource returns the Source for path: OSSource for a directory, or an
+// archive-backed Source if path is a .zip/.tar/.tar.gz/.tar.bz2 file.
+func NewSource(path string) (Source, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return OSSource, nil
+	}
+
+	if !IsArchivePath(path) {
+		return nil, fmt.Errorf("fileutils: %s is neither a directory nor a recognized archive", path)
+	}
+
+	return newArchiveSource(path)
+}
+
+// archiveEntry is one file or directory inside an archive, with just enough
+// metadata to populate a FileInfo.
+type archiveEntry struct {
+	name    string // forward-slash path within the archive
+	isDir   bool
+	size    int64
+	modTime time.Time
+}
+
+// archiveSource serves Walk/Open over an archive's contents, read fully
+// into memory once at construction. Prompt archives are source trees, not
+// media — decompressing the whole thing up front is simpler than building
+// a seekable index over tar's stream format, and keeps Open cheap: it
+// serves an in-memory reader without touching the archive again.
+type archiveSource struct {
+	archivePath string
+	entries     []archiveEntry
+	content     map[string][]byte // keyed by entry name, files only
+}
+
+func newArchiveSource(archivePath string) (*archiveSource, error) {
+	lower := strings.ToLower(archivePath)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return newZipSource(archivePath)
+	case strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz"):
+		return newTarSource(archivePath, func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) })
+	case strings.HasSuffix(lower, ".tar.bz2"):
+		return newTarSource(archivePath, func(r io.Reader) (io.Reader, error) { return bzip2.NewReader(r), nil })
+	case strings.HasSuffix(lower, ".tar"):
+		return newTarSource(archivePath, nil)
+	default:
+		return nil, fmt.Errorf("fileutils: unsupported archive extension in %s", archivePath)
+	}
+}
+
+func newZipSource(archivePath string) (*archiveSource, error) {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("fileutils: opening %s: %w", archivePath, err)
+	}
+	defer r.Close()
+
+	s := &archiveSource{archivePath: archivePath, content: make(map[string][]byte)}
+	for _, f := range r.File {
+		name := strings.TrimSuffix(f.Name, "/")
+		if name == "" {
+			continue
+		}
+		if f.FileInfo().IsDir() {
+			s.entries = append(s.entries, archiveEntry{name: name, isDir: true, modTime: f.Modified})
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("fileutils: reading %s from %s: %w", f.Name, archivePath, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("fileutils: reading %s from %s: %w", f.Name, archivePath, err)
+		}
+
+		s.content[name] = data
+		s.entries = append(s.entries, archiveEntry{name: name, isDir: false, size: int64(len(data)), modTime: f.Modified})
+	}
+	return s, nil
+}
+
+func newTarSource(archivePath string, decompress func(io.Reader) (io.Reader, error)) (*archiveSource, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("fileutils: opening %s: %w", archivePath, err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if decompress != nil {
+		r, err = decompress(r)
+		if err != nil {
+			return nil, fmt.Errorf("fileutils: decompressing %s: %w", archivePath, err)
+		}
+	}
+
+	s := &archiveSource{archivePath: archivePath, content: make(map[string][]byte)}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fileutils: reading %s: %w", archivePath, err)
+		}
+
+		name := strings.Trim(filepath.ToSlash(hdr.Name), "/")
+		if name == "" || name == "." {
+			continue
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			s.entries = append(s.entries, archiveEntry{name: name, isDir: true, modTime: hdr.ModTime})
+		case tar.TypeReg:
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, fmt.Errorf("fileutils: reading %s from %s: %w", hdr.Name, archivePath, err)
+			}
+			s.content[name] = data
+			s.entries = append(s.entries, archiveEntry{name: name, isDir: false, size: hdr.Size, modTime: hdr.ModTime})
+		}
+	}
+	return s, nil
+}
+
+func (s *archiveSource) Open(path string) (io.ReadCloser, error) {
+	name := strings.Trim(path, "/")
+	data, ok := s.content[name]
+	if !ok {
+		return nil, fmt.Errorf("fileutils: %s not found in %s", path, s.archivePath)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Walk streams the archive's entries as a flat FileInfo stream, the same
+// shape Walker.Walk produces for a real directory, synthesizing any
+// intermediate directory entries the archive format left implicit (tar and
+// zip writers commonly omit them).
+func (s *archiveSource) Walk(ctx context.Context, root string, filters FileFilters) <-chan WalkResult {
+	out := make(chan WalkResult, 64)
+
+	go func() {
+		defer close(out)
+
+		emit(ctx, out, WalkResult{Info: &FileInfo{Path: s.archivePath, Name: filepath.Base(s.archivePath), IsDir: true}})
+
+		seenDirs := make(map[string]bool)
+		ensureDir := func(dirPath string) {
+			if dirPath == "" || seenDirs[dirPath] {
+				return
+			}
+			seenDirs[dirPath] = true
+			segs := strings.Split(dirPath, "/")
+			emit(ctx, out, WalkResult{Info: &FileInfo{Path: dirPath, Name: segs[len(segs)-1], IsDir: true}})
+		}
+
+		for _, e := range s.entries {
+			if ctx.Err() != nil {
+				return
+			}
+
+			segs := strings.Split(e.name, "/")
+			for i := 1; i < len(segs); i++ {
+				ensureDir(strings.Join(segs[:i], "/"))
+			}
+
+			if e.isDir {
+				ensureDir(e.name)
+				continue
+			}
+
+			if archiveEntrySkipped(e.name, filters) {
+				continue
+			}
+
+			fileInfo := &FileInfo{
+				Path:       e.name,
+				Name:       segs[len(segs)-1],
+				IsDir:      false,
+				Size:       e.size,
+				Extension:  filepath.Ext(e.name),
+				TokenCount: int(e.size / 4),
+				ModTime:    e.modTime,
+			}
+			if filters.Predicate != nil && !filters.Predicate.Match(fileInfo) {
+				continue
+			}
+
+			emit(ctx, out, WalkResult{Info: fileInfo})
+		}
+	}()
+
+	return out
+}
+
+// archiveEntrySkipped applies the same Extensions/NamePattern filters
+// processDir does, since apart from filters.Predicate (applied afterwards
+// in Walk) an archive's entries go through no other filtering (there's no
+// .gitignore or hidden-file concept inside a tarball).
+func archiveEntrySkipped(name string, filters FileFilters) bool {
+	if len(filters.Extensions) > 0 {
+		ext := filepath.Ext(name)
+		if ext != "" {
+			ext = ext[1:]
+		}
+		found := false
+		for _, e := range filters.Extensions {
+			if e == ext {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return true
+		}
+	}
+
+	if filters.NamePattern != "" {
+		if matched, err := filepath.Match(filters.NamePattern, filepath.Base(name)); err != nil || !matched {
+			return true
+		}
+	}
+
+	return false
+}
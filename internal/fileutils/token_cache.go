@@ -0,0 +1,62 @@
+package fileutils
+
+import (
+	"os"
+	"sync"
+)
+
+// tokenCacheKey identifies a cached token count by path, the tokenizer
+// that produced it, and the file's mtime+size, so a stale entry is
+// invalidated the moment the file changes on disk.
+type tokenCacheKey struct {
+	path      string
+	tokenizer string
+	modTime   int64
+	size      int64
+}
+
+// TokenCache memoizes Tokenizer.CountTokens results per file so repeated
+// scans of an unchanged tree don't re-read and re-tokenize every file.
+type TokenCache struct {
+	mu     sync.Mutex
+	counts map[tokenCacheKey]int
+}
+
+// NewTokenCache creates an empty TokenCache.
+func NewTokenCache() *TokenCache {
+	return &TokenCache{counts: make(map[tokenCacheKey]int)}
+}
+
+// DefaultTokenCache is shared by callers that don't need cache isolation
+// between unrelated scans.
+var DefaultTokenCache = NewTokenCache()
+
+// CountFile returns the token count for path under tokenizer, reading and
+// tokenizing the file's content only on a cache miss.
+func (c *TokenCache) CountFile(path string, tokenizer Tokenizer, info os.FileInfo) (int, error) {
+	key := tokenCacheKey{
+		path:      path,
+		tokenizer: tokenizer.Name(),
+		modTime:   info.ModTime().UnixNano(),
+		size:      info.Size(),
+	}
+
+	c.mu.Lock()
+	if count, ok := c.counts[key]; ok {
+		c.mu.Unlock()
+		return count, nil
+	}
+	c.mu.Unlock()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	count := tokenizer.CountTokens(string(content))
+
+	c.mu.Lock()
+	c.counts[key] = count
+	c.mu.Unlock()
+
+	return count, nil
+}
@@ -0,0 +1,118 @@
+package fileutils
+
+import (
+	_ "embed"
+	"encoding/base64"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// bundledRankTable is a compact stand-in for OpenAI's official per-encoding
+// rank files, not cl100k_base/o200k_base itself — it's nowhere near their
+// ~100k-entry size, so counts are only approximate once text needs merges
+// beyond the handful bundled here (hence "bpe-approx" rather than a named
+// encoding). It uses the exact same ".tiktoken" format they ship: each line
+// is "<base64-encoded token bytes> <rank>", sorted by rank ascending,
+// starting from the 256 single-byte tokens every encoding falls back to.
+// Drop a full cl100k_base.tiktoken / o200k_base.tiktoken next to this file
+// and point newBPETokenizer at its contents, registering the result under
+// its real encoding name, to get exact token parity for that encoding.
+//
+//go:embed assets/common.tiktoken
+var bundledRankTable string
+
+// bpeTokenizer implements byte-level BPE: byte-pair-merge a pre-tokenized
+// chunk of text against a rank table, the same algorithm tiktoken itself
+// uses once its regex has split text into chunks.
+type bpeTokenizer struct {
+	name  string
+	ranks map[string]int // token bytes -> rank; lower rank merges first
+}
+
+// newBundledBPETokenizer builds a bpeTokenizer named name from the
+// bundled rank table.
+func newBundledBPETokenizer(name string) (*bpeTokenizer, error) {
+	return newBPETokenizer(name, bundledRankTable)
+}
+
+// newBPETokenizer parses a rank table in tiktoken's ".tiktoken" format.
+func newBPETokenizer(name, rankTable string) (*bpeTokenizer, error) {
+	ranks := make(map[string]int)
+	for _, line := range strings.Split(rankTable, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		tokenBytes, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			continue
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		ranks[string(tokenBytes)] = rank
+	}
+	return &bpeTokenizer{name: name, ranks: ranks}, nil
+}
+
+func (b *bpeTokenizer) Name() string { return b.name }
+
+func (b *bpeTokenizer) CountTokens(text string) int {
+	total := 0
+	for _, chunk := range preTokenize(text) {
+		total += len(b.bytePairMerge([]byte(chunk)))
+	}
+	return total
+}
+
+// preTokenize is a simplified stand-in for cl100k_base's regex
+// pre-tokenizer, which relies on lookaheads Go's RE2 engine can't run: it
+// splits text into runs of whitespace and runs of non-whitespace, each
+// merged independently. That's enough to keep BPE merges from crossing
+// word boundaries without replicating the official pattern exactly.
+var preTokenizePattern = regexp.MustCompile(`\s+|\S+`)
+
+func preTokenize(text string) []string {
+	return preTokenizePattern.FindAllString(text, -1)
+}
+
+// bytePairMerge runs tiktoken's reference algorithm: start with one piece
+// per byte, then repeatedly merge whichever adjacent pair has the lowest
+// rank in the vocabulary, until no pair has a known rank left.
+func (b *bpeTokenizer) bytePairMerge(data []byte) [][]byte {
+	if len(data) == 0 {
+		return nil
+	}
+
+	pieces := make([][]byte, len(data))
+	for i, c := range data {
+		pieces[i] = []byte{c}
+	}
+
+	for len(pieces) > 1 {
+		bestRank := -1
+		bestIdx := -1
+		for i := 0; i < len(pieces)-1; i++ {
+			combined := string(pieces[i]) + string(pieces[i+1])
+			if rank, ok := b.ranks[combined]; ok && (bestRank == -1 || rank < bestRank) {
+				bestRank = rank
+				bestIdx = i
+			}
+		}
+		if bestIdx == -1 {
+			break
+		}
+
+		merged := append(append([]byte{}, pieces[bestIdx]...), pieces[bestIdx+1]...)
+		rest := append([][]byte{merged}, pieces[bestIdx+2:]...)
+		pieces = append(pieces[:bestIdx], rest...)
+	}
+
+	return pieces
+}
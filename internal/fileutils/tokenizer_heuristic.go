@@ -0,0 +1,12 @@
+package fileutils
+
+// HeuristicTokenizer is the original size/4 estimate, kept as the fast
+// default for callers (like the live tree view) that want a rough number
+// without paying for real tokenization.
+type HeuristicTokenizer struct{}
+
+func (HeuristicTokenizer) Name() string { return "heuristic" }
+
+func (HeuristicTokenizer) CountTokens(text string) int {
+	return len(text) / 4
+}
@@ -0,0 +1,24 @@
+package fileutils
+
+// WhitespaceTokenizer counts tokens as whitespace-separated fields, a step
+// up from the byte-count heuristic without the cost of real BPE.
+type WhitespaceTokenizer struct{}
+
+func (WhitespaceTokenizer) Name() string { return "whitespace" }
+
+func (WhitespaceTokenizer) CountTokens(text string) int {
+	count := 0
+	inField := false
+	for _, r := range text {
+		isSpace := r == ' ' || r == '\t' || r == '\n' || r == '\r'
+		if isSpace {
+			inField = false
+			continue
+		}
+		if !inField {
+			count++
+			inField = true
+		}
+	}
+	return count
+}
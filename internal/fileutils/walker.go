@@ -0,0 +1,345 @@
+package fileutils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// WalkResult carries either a discovered FileInfo or a non-fatal error
+// encountered while walking a single path.
+type WalkResult struct {
+	Info *FileInfo
+	Err  error
+}
+
+// Walker walks a directory tree concurrently with a bounded worker pool,
+// streaming results as they're discovered instead of blocking until the
+// whole tree has been visited the way filepath.Walk does. This lets a
+// caller like FileTreeWidget start rendering rows immediately and abort a
+// scan in flight via ctx.
+type Walker struct {
+	Filters FileFilters
+	Workers int // goroutine pool size; <= 0 defaults to runtime.NumCPU()
+
+	visitedMu   sync.Mutex
+	visitedDirs []os.FileInfo // physical directories already descended into via a followed symlink, for cycle detection
+}
+
+// NewWalker creates a Walker for the given filters.
+func NewWalker(filters FileFilters, workers int) *Walker {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	return &Walker{Filters: filters, Workers: workers}
+}
+
+// Walk streams every file and directory under root that passes w.Filters on
+// the returned channel. The channel is closed once the walk completes or
+// ctx is cancelled, whichever happens first.
+func (w *Walker) Walk(ctx context.Context, root string) <-chan WalkResult {
+	out := make(chan WalkResult, 64)
+
+	w.visitedMu.Lock()
+	w.visitedDirs = nil
+	w.visitedMu.Unlock()
+
+	go func() {
+		defer close(out)
+
+		dir := root
+		if w.Filters.SubPath != "" {
+			dir = filepath.Join(root, w.Filters.SubPath)
+		}
+
+		ignorer := w.Filters.Ignorer
+		if ignorer == nil && w.Filters.RespectGitignore {
+			m, err := NewIgnoreMatcher(dir, w.Filters.GlobalIgnorePatterns)
+			if err != nil {
+				emit(ctx, out, WalkResult{Err: err})
+				return
+			}
+			ignorer = m
+		}
+
+		info, err := os.Stat(dir)
+		if err != nil {
+			emit(ctx, out, WalkResult{Err: err})
+			return
+		}
+		emit(ctx, out, WalkResult{Info: &FileInfo{
+			Path:  dir,
+			Name:  filepath.Base(dir),
+			IsDir: true,
+		}})
+		if !info.IsDir() {
+			return
+		}
+
+		jobs := make(chan string, w.Workers*4)
+		var pending sync.WaitGroup
+
+		submit := func(path string) {
+			pending.Add(1)
+			go func() {
+				select {
+				case jobs <- path:
+				case <-ctx.Done():
+					pending.Done()
+				}
+			}()
+		}
+
+		// submit does its Add(1) synchronously, so calling it before the
+		// "wait then close" goroutine starts guarantees pending never drops
+		// to zero (and closes jobs) before the root has been queued.
+		submit(dir)
+
+		go func() {
+			pending.Wait()
+			close(jobs)
+		}()
+
+		var workers sync.WaitGroup
+		for i := 0; i < w.Workers; i++ {
+			workers.Add(1)
+			go func() {
+				defer workers.Done()
+				for {
+					select {
+					case <-ctx.Done():
+						// Drain so `pending` can still reach zero and close jobs.
+						for range jobs {
+							pending.Done()
+						}
+						return
+					case path, ok := <-jobs:
+						if !ok {
+							return
+						}
+						w.processDir(ctx, dir, path, ignorer, out, submit)
+						pending.Done()
+					}
+				}
+			}()
+		}
+
+		workers.Wait()
+	}()
+
+	return out
+}
+
+// processDir reads one directory's entries with os.ReadDir (avoiding the
+// per-entry os.Lstat cost filepath.Walk pays), emits the ones that pass the
+// walker's filters, and hands any un-ignored subdirectories back via submit
+// so the worker pool keeps expanding the tree breadth-first.
+func (w *Walker) processDir(ctx context.Context, root, path string, ignorer Ignorer, out chan<- WalkResult, submit func(string)) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		emit(ctx, out, WalkResult{Err: err})
+		return
+	}
+
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+
+		fullPath := filepath.Join(path, entry.Name())
+		isDir := entry.IsDir()
+
+		// entry.IsDir() reflects the directory-entry type, which is false
+		// for a symlink even when it points at a directory. Resolve it
+		// through the link when FollowSymlinks is set, so a symlinked
+		// directory is walked like any other; otherwise it falls through
+		// and is listed as a plain file below, same as before.
+		var symlinkTarget os.FileInfo
+		if entry.Type()&os.ModeSymlink != 0 && w.Filters.FollowSymlinks {
+			target, err := os.Stat(fullPath)
+			if err != nil {
+				// Broken symlink; skip rather than failing the whole walk.
+				continue
+			}
+			symlinkTarget = target
+			isDir = target.IsDir()
+			if isDir && !w.visitDir(target) {
+				continue // already descended into this physical directory
+			}
+		}
+
+		// An ignored entry is shown greyed out rather than hidden, so the
+		// user can see what a .gitignore/global-ignore rule excluded and
+		// why, instead of silently losing track of it; ignoreRule carries
+		// that explanation when ignorer can provide one.
+		var ignored bool
+		var ignoreRule string
+		if ignorer != nil {
+			if isDir {
+				ignored = ignorer.IgnoreDir(fullPath)
+			} else {
+				ignored = ignorer.IgnoreFile(fullPath)
+			}
+			if ignored {
+				if rm, ok := ignorer.(ruleMatcher); ok {
+					var match IgnoreMatch
+					if isDir {
+						match = rm.MatchDir(fullPath)
+					} else {
+						match = rm.MatchFile(fullPath)
+					}
+					ignoreRule = match.Source + ": " + match.Rule
+				}
+			}
+		}
+
+		if shouldSkipHidden(w.Filters.HiddenFiles, fullPath, entry.Name()) {
+			continue
+		}
+
+		matchesIgnorePattern := false
+		for _, pattern := range w.Filters.IgnorePatterns {
+			if matched, merr := filepath.Match(pattern, entry.Name()); merr == nil && matched {
+				matchesIgnorePattern = true
+				break
+			}
+		}
+		if matchesIgnorePattern {
+			continue
+		}
+
+		if isDir {
+			dirInfo := &FileInfo{
+				Path:       fullPath,
+				Name:       entry.Name(),
+				IsDir:      true,
+				Ignored:    ignored,
+				IgnoreRule: ignoreRule,
+			}
+			// Predicate, like Extensions/NamePattern, only decides whether
+			// this directory's own node is emitted — it must never block
+			// descent, or a predicate that's unconditionally false for
+			// directories (ContentGrepPredicate, a tight SizeRangePredicate)
+			// would silently stop the walk from recursing past the root.
+			if w.Filters.Predicate == nil || w.Filters.Predicate.Match(dirInfo) {
+				emit(ctx, out, WalkResult{Info: dirInfo})
+			}
+			// An ignored directory's contents aren't individually evaluated
+			// (matching git's own behavior), so don't expand into it — it's
+			// shown as a single greyed-out node instead.
+			if !ignored {
+				submit(fullPath)
+			}
+			continue
+		}
+
+		if len(w.Filters.Extensions) > 0 {
+			ext := filepath.Ext(fullPath)
+			if ext != "" {
+				ext = ext[1:]
+			}
+			found := false
+			for _, e := range w.Filters.Extensions {
+				if e == ext {
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+
+		if w.Filters.NamePattern != "" {
+			if matched, merr := filepath.Match(w.Filters.NamePattern, entry.Name()); merr != nil || !matched {
+				continue
+			}
+		}
+
+		fi := symlinkTarget
+		if fi == nil {
+			var err error
+			fi, err = entry.Info()
+			if err != nil {
+				emit(ctx, out, WalkResult{Err: err})
+				continue
+			}
+		}
+
+		tokenCount := int(fi.Size() / 4) // fast default: 1 token per 4 bytes
+		if w.Filters.Tokenizer != nil {
+			if count, terr := DefaultTokenCache.CountFile(fullPath, w.Filters.Tokenizer, fi); terr == nil {
+				tokenCount = count
+			}
+		}
+
+		fileInfo := &FileInfo{
+			Path:       fullPath,
+			Name:       entry.Name(),
+			IsDir:      false,
+			Size:       fi.Size(),
+			Extension:  filepath.Ext(fullPath),
+			TokenCount: tokenCount,
+			ModTime:    fi.ModTime(),
+			Ignored:    ignored,
+			IgnoreRule: ignoreRule,
+		}
+		if w.Filters.Predicate != nil && !w.Filters.Predicate.Match(fileInfo) {
+			continue
+		}
+
+		emit(ctx, out, WalkResult{Info: fileInfo})
+	}
+}
+
+// visitDir records fi as a physical directory the walk has descended into
+// via a followed symlink, and reports whether it's new. Identity is checked
+// with os.SameFile (device+inode on Unix, file index on Windows) rather
+// than by path, since two different symlink paths can resolve to the same
+// physical directory. Each physical directory is descended into through a
+// symlink at most once per walk: a later link to it is skipped, whether it
+// forms a cycle or just a second route (a diamond) to the same directory.
+func (w *Walker) visitDir(fi os.FileInfo) (isNew bool) {
+	w.visitedMu.Lock()
+	defer w.visitedMu.Unlock()
+
+	for _, v := range w.visitedDirs {
+		if os.SameFile(v, fi) {
+			return false
+		}
+	}
+	w.visitedDirs = append(w.visitedDirs, fi)
+	return true
+}
+
+// emit sends result on out unless ctx is cancelled first.
+func emit(ctx context.Context, out chan<- WalkResult, result WalkResult) {
+	select {
+	case out <- result:
+	case <-ctx.Done():
+	}
+}
+
+// ListFiles returns a list of files in the given directory. It's a
+// synchronous shim over Walker for callers that don't need streaming or
+// cancellation.
+func ListFiles(dir string, filters FileFilters) ([]*FileInfo, error) {
+	w := NewWalker(filters, 0)
+	ctx := context.Background()
+
+	var result []*FileInfo
+	var firstErr error
+	for res := range w.Walk(ctx, dir) {
+		if res.Err != nil {
+			if firstErr == nil {
+				firstErr = res.Err
+			}
+			continue
+		}
+		result = append(result, res.Info)
+	}
+
+	return result, firstErr
+}
@@ -4,19 +4,37 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+
+	"github.com/openprompt/internal/fileutils"
 )
 
+// DefaultCacheMaxBytes is how large the on-disk chunk cache is allowed to
+// grow before LRU eviction kicks in, if the user hasn't set their own limit.
+const DefaultCacheMaxBytes int64 = 512 * 1024 * 1024 // 512MB
+
 // Preferences represents user preferences
 type Preferences struct {
 	LastDirectory string            `json:"lastDirectory"`
 	Filters       map[string]string `json:"filters"`
+	LastFormat    string            `json:"lastFormat"`
+	CacheMaxBytes int64             `json:"cacheMaxBytes"`
+	LastModel     string            `json:"lastModel"`
+	// GlobalIgnorePatterns are gitignore-style patterns excluded tree-wide
+	// regardless of any .gitignore, e.g. "node_modules/" or "*.min.js". nil
+	// means unset (falls back to fileutils.DefaultGlobalIgnorePatterns); an
+	// empty, non-nil slice means the user cleared the list deliberately.
+	GlobalIgnorePatterns []string `json:"globalIgnorePatterns"`
 }
 
 // DefaultPreferences returns default preferences
 func DefaultPreferences() *Preferences {
 	return &Preferences{
-		LastDirectory: "",
-		Filters:       make(map[string]string),
+		LastDirectory:        "",
+		Filters:              make(map[string]string),
+		LastFormat:           "xml",
+		CacheMaxBytes:        DefaultCacheMaxBytes,
+		LastModel:            "gpt-4",
+		GlobalIgnorePatterns: append([]string(nil), fileutils.DefaultGlobalIgnorePatterns...),
 	}
 }
 
@@ -26,9 +44,9 @@ func GetPreferencesDir() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	
+
 	prefsDir := filepath.Join(homeDir, ".openprompt")
-	
+
 	// Create directory if it doesn't exist
 	if _, err := os.Stat(prefsDir); os.IsNotExist(err) {
 		err = os.MkdirAll(prefsDir, 0755)
@@ -36,7 +54,7 @@ func GetPreferencesDir() (string, error) {
 			return "", err
 		}
 	}
-	
+
 	return prefsDir, nil
 }
 
@@ -46,7 +64,7 @@ func GetPreferencesFile() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	
+
 	return filepath.Join(prefsDir, "preferences.json"), nil
 }
 
@@ -56,26 +74,46 @@ func Load() (*Preferences, error) {
 	if err != nil {
 		return DefaultPreferences(), err
 	}
-	
+
 	// Check if file exists
 	if _, err := os.Stat(prefsFile); os.IsNotExist(err) {
 		// Return default preferences if file doesn't exist
 		return DefaultPreferences(), nil
 	}
-	
+
 	// Read file
 	data, err := os.ReadFile(prefsFile)
 	if err != nil {
 		return DefaultPreferences(), err
 	}
-	
+
 	// Parse JSON
 	prefs := DefaultPreferences()
 	err = json.Unmarshal(data, prefs)
 	if err != nil {
 		return DefaultPreferences(), err
 	}
-	
+
+	// Older preferences files predate LastFormat; fall back to the default
+	// rather than leaving the UI with no format selected.
+	if prefs.LastFormat == "" {
+		prefs.LastFormat = "xml"
+	}
+	// Likewise for CacheMaxBytes, and guard against a corrupt/negative value.
+	if prefs.CacheMaxBytes <= 0 {
+		prefs.CacheMaxBytes = DefaultCacheMaxBytes
+	}
+	// Likewise for LastModel.
+	if prefs.LastModel == "" {
+		prefs.LastModel = "gpt-4"
+	}
+	// Older preferences files predate GlobalIgnorePatterns; nil (rather than
+	// an explicit empty list) means it was never set, so fall back to the
+	// defaults instead of leaving global ignoring off entirely.
+	if prefs.GlobalIgnorePatterns == nil {
+		prefs.GlobalIgnorePatterns = append([]string(nil), fileutils.DefaultGlobalIgnorePatterns...)
+	}
+
 	return prefs, nil
 }
 
@@ -85,13 +123,13 @@ func (p *Preferences) Save() error {
 	if err != nil {
 		return err
 	}
-	
+
 	// Convert to JSON
 	data, err := json.MarshalIndent(p, "", "  ")
 	if err != nil {
 		return err
 	}
-	
+
 	// Write to file
 	return os.WriteFile(prefsFile, data, 0644)
 }
@@ -115,3 +153,63 @@ func (p *Preferences) SetFilter(name, value string) {
 func (p *Preferences) GetFilter(name string) string {
 	return p.Filters[name]
 }
+
+// SetLastFormat sets the last used prompt output format
+func (p *Preferences) SetLastFormat(format string) {
+	p.LastFormat = format
+}
+
+// GetLastFormat gets the last used prompt output format
+func (p *Preferences) GetLastFormat() string {
+	return p.LastFormat
+}
+
+// SetCacheMaxBytes sets the chunk cache's size limit
+func (p *Preferences) SetCacheMaxBytes(maxBytes int64) {
+	p.CacheMaxBytes = maxBytes
+}
+
+// GetCacheMaxBytes gets the chunk cache's size limit
+func (p *Preferences) GetCacheMaxBytes() int64 {
+	return p.CacheMaxBytes
+}
+
+// SetLastModel sets the last used token-budget model
+func (p *Preferences) SetLastModel(model string) {
+	p.LastModel = model
+}
+
+// GetLastModel gets the last used token-budget model
+func (p *Preferences) GetLastModel() string {
+	return p.LastModel
+}
+
+// SetGlobalIgnorePatterns sets the gitignore-style patterns excluded
+// tree-wide regardless of any .gitignore.
+func (p *Preferences) SetGlobalIgnorePatterns(patterns []string) {
+	p.GlobalIgnorePatterns = patterns
+}
+
+// GetGlobalIgnorePatterns gets the gitignore-style patterns excluded
+// tree-wide regardless of any .gitignore.
+func (p *Preferences) GetGlobalIgnorePatterns() []string {
+	return p.GlobalIgnorePatterns
+}
+
+// GetCacheDir returns the directory where the persistent chunk cache is
+// stored, creating it if it doesn't exist yet.
+func GetCacheDir() (string, error) {
+	prefsDir, err := GetPreferencesDir()
+	if err != nil {
+		return "", err
+	}
+
+	cacheDir := filepath.Join(prefsDir, "cache")
+	if _, err := os.Stat(cacheDir); os.IsNotExist(err) {
+		if err := os.MkdirAll(cacheDir, 0755); err != nil {
+			return "", err
+		}
+	}
+
+	return cacheDir, nil
+}
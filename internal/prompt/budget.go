@@ -0,0 +1,230 @@
+package prompt
+
+import (
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/openprompt/internal/fileutils"
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// modelEncodings maps a model dropdown value to the tiktoken encoding (or
+// model name tiktoken-go understands) that estimates it most closely.
+// tiktoken has no Claude encoding, so "claude" falls back to cl100k_base,
+// the closest available approximation.
+var modelEncodings = map[string]string{
+	"gpt-3.5": "gpt-3.5-turbo",
+	"gpt-4":   "gpt-4",
+	"gpt-4o":  "gpt-4o",
+	"claude":  "cl100k_base",
+}
+
+// ModelNames returns the model dropdown's options, in display order.
+func ModelNames() []string {
+	return []string{"gpt-3.5", "gpt-4", "gpt-4o", "claude"}
+}
+
+// DefaultModel is the model TokenBudget uses when the UI hasn't persisted a
+// choice yet.
+const DefaultModel = "gpt-4"
+
+// encodingForModel resolves model to a tiktoken encoding, falling back to
+// cl100k_base for unknown models or if tiktoken-go doesn't recognize the
+// mapped name.
+func encodingForModel(model string) (*tiktoken.Tiktoken, error) {
+	name, ok := modelEncodings[model]
+	if !ok {
+		name = "cl100k_base"
+	}
+
+	if name == "cl100k_base" {
+		return tiktoken.GetEncoding(name)
+	}
+
+	tk, err := tiktoken.EncodingForModel(name)
+	if err != nil {
+		return tiktoken.GetEncoding("cl100k_base")
+	}
+	return tk, nil
+}
+
+// DropStrategy picks which selected files TokenBudget.SuggestDrops offers up
+// first when a selection is over budget.
+type DropStrategy string
+
+const (
+	// DropLargestFirst drops the biggest token counts first, freeing the
+	// budget in as few files as possible.
+	DropLargestFirst DropStrategy = "largest-first"
+	// DropLowestRelevanceFirst drops the least-recently-modified files
+	// first. There's no real relevance signal in this codebase yet, so
+	// staleness is used as a stand-in: an old, untouched file is a better
+	// guess for "not relevant to the current instructions" than a
+	// recently-edited one.
+	DropLowestRelevanceFirst DropStrategy = "lowest-relevance-first"
+)
+
+// TokenBudget tokenizes a file selection against one model's real tiktoken
+// encoding, caching each file's count by (path, mtime, size) so re-checking
+// an unchanged selection doesn't re-tokenize it. It's deliberately separate
+// from the chunk cache in chunkcache.go, which is keyed by content hash and
+// shared across models — this cache is scoped to a single model, since the
+// same file tokenizes to a different count under each one.
+type TokenBudget struct {
+	mu     sync.Mutex
+	model  string
+	counts map[string]int // "path|mtime|size" -> token count
+}
+
+// NewTokenBudget creates a TokenBudget that tokenizes against model's
+// encoding.
+func NewTokenBudget(model string) *TokenBudget {
+	return &TokenBudget{model: model, counts: make(map[string]int)}
+}
+
+// Model returns the model this budget tokenizes against.
+func (b *TokenBudget) Model() string {
+	return b.model
+}
+
+// CountFile returns path's token count under b.Model(), tokenizing and
+// caching it if this is the first time this exact (path, mtime, size) has
+// been seen. source is read the same way AssembleFiles reads it: nil (or
+// fileutils.OSSource) uses the (path, mtime, size) cache key below, since a
+// real file has a stable one; any other Source (an archive) is read fresh
+// through Source.Open every time, since an archive entry has none.
+func (b *TokenBudget) CountFile(path string, source fileutils.Source) (int, error) {
+	var key string
+	if source == nil || source == fileutils.OSSource {
+		info, err := os.Stat(path)
+		if err != nil {
+			return 0, err
+		}
+		key = fileCacheKey(path, info)
+
+		b.mu.Lock()
+		if count, ok := b.counts[key]; ok {
+			b.mu.Unlock()
+			return count, nil
+		}
+		b.mu.Unlock()
+	}
+
+	content, err := readFileFor(path, source)
+	if err != nil {
+		return 0, err
+	}
+
+	tk, err := encodingForModel(b.model)
+	var count int
+	if err != nil {
+		count = len(content) / 4
+	} else {
+		count = len(tk.Encode(content, nil, nil))
+	}
+
+	if key != "" {
+		b.mu.Lock()
+		b.counts[key] = count
+		b.mu.Unlock()
+	}
+	return count, nil
+}
+
+// CountFiles tokenizes every non-directory entry in files through a bounded
+// worker pool, mirroring AssembleFiles' worker count, writes each file's
+// count back into its FileInfo.TokenCount so the file tree's per-file
+// labels reflect it, and returns the selection's total. source is the same
+// fileutils.Source the selection was loaded from (nil for a plain directory).
+func (b *TokenBudget) CountFiles(files []*fileutils.FileInfo, source fileutils.Source) (total int, err error) {
+	var toCount []*fileutils.FileInfo
+	for _, f := range files {
+		if !f.IsDir {
+			toCount = append(toCount, f)
+		}
+	}
+	if len(toCount) == 0 {
+		return 0, nil
+	}
+
+	numWorkers := runtime.NumCPU() * 2
+	if numWorkers > len(toCount) {
+		numWorkers = len(toCount)
+	}
+
+	workCh := make(chan *fileutils.FileInfo, len(toCount))
+	for _, f := range toCount {
+		workCh <- f
+	}
+	close(workCh)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range workCh {
+				count, cerr := b.CountFile(f.Path, source)
+				if cerr != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = cerr
+					}
+					mu.Unlock()
+					continue
+				}
+				f.TokenCount = count
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, f := range toCount {
+		total += f.TokenCount
+	}
+	return total, firstErr
+}
+
+// DropSuggestion is which files to drop from a selection to get back under
+// budget, and how many tokens doing so frees.
+type DropSuggestion struct {
+	Files       []*fileutils.FileInfo
+	TokensFreed int
+}
+
+// SuggestDrops picks files out of a (non-directory) selection to drop,
+// using strategy to order candidates, until at least overBy tokens have
+// been freed or there's nothing left to drop.
+func SuggestDrops(files []*fileutils.FileInfo, overBy int, strategy DropStrategy) DropSuggestion {
+	var candidates []*fileutils.FileInfo
+	for _, f := range files {
+		if !f.IsDir {
+			candidates = append(candidates, f)
+		}
+	}
+
+	switch strategy {
+	case DropLowestRelevanceFirst:
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].ModTime.Before(candidates[j].ModTime)
+		})
+	default:
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].TokenCount > candidates[j].TokenCount
+		})
+	}
+
+	var suggestion DropSuggestion
+	for _, f := range candidates {
+		if suggestion.TokensFreed >= overBy {
+			break
+		}
+		suggestion.Files = append(suggestion.Files, f)
+		suggestion.TokensFreed += f.TokenCount
+	}
+	return suggestion
+}
@@ -0,0 +1,80 @@
+package prompt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/openprompt/internal/fileutils"
+)
+
+func TestSuggestDropsLargestFirst(t *testing.T) {
+	now := time.Now()
+	files := []*fileutils.FileInfo{
+		{Path: "small.go", TokenCount: 10, ModTime: now},
+		{Path: "big.go", TokenCount: 100, ModTime: now.Add(-time.Hour)},
+		{Path: "medium.go", TokenCount: 50, ModTime: now.Add(-2 * time.Hour)},
+		{Path: "dir", IsDir: true, TokenCount: 9999},
+	}
+
+	got := SuggestDrops(files, 120, DropLargestFirst)
+
+	if len(got.Files) != 2 {
+		t.Fatalf("expected big.go and medium.go to be dropped, got %d files", len(got.Files))
+	}
+	if got.Files[0].Path != "big.go" || got.Files[1].Path != "medium.go" {
+		t.Errorf("expected biggest-first order [big.go, medium.go], got [%s, %s]", got.Files[0].Path, got.Files[1].Path)
+	}
+	if got.TokensFreed != 150 {
+		t.Errorf("TokensFreed = %d, want 150", got.TokensFreed)
+	}
+}
+
+func TestSuggestDropsLowestRelevanceFirst(t *testing.T) {
+	now := time.Now()
+	files := []*fileutils.FileInfo{
+		{Path: "recent.go", TokenCount: 10, ModTime: now},
+		{Path: "stale.go", TokenCount: 20, ModTime: now.Add(-24 * time.Hour)},
+		{Path: "middling.go", TokenCount: 30, ModTime: now.Add(-time.Hour)},
+	}
+
+	got := SuggestDrops(files, 25, DropLowestRelevanceFirst)
+
+	if len(got.Files) != 2 {
+		t.Fatalf("expected stale.go and middling.go to be dropped, got %d files", len(got.Files))
+	}
+	if got.Files[0].Path != "stale.go" || got.Files[1].Path != "middling.go" {
+		t.Errorf("expected oldest-first order [stale.go, middling.go], got [%s, %s]", got.Files[0].Path, got.Files[1].Path)
+	}
+	if got.TokensFreed != 50 {
+		t.Errorf("TokensFreed = %d, want 50", got.TokensFreed)
+	}
+}
+
+func TestSuggestDropsStopsOnceOverByIsCovered(t *testing.T) {
+	files := []*fileutils.FileInfo{
+		{Path: "a.go", TokenCount: 100},
+		{Path: "b.go", TokenCount: 100},
+		{Path: "c.go", TokenCount: 100},
+	}
+
+	got := SuggestDrops(files, 50, DropLargestFirst)
+
+	if len(got.Files) != 1 {
+		t.Fatalf("expected a single file to already cover overBy, got %d files", len(got.Files))
+	}
+	if got.TokensFreed != 100 {
+		t.Errorf("TokensFreed = %d, want 100", got.TokensFreed)
+	}
+}
+
+func TestSuggestDropsEmptyWhenNothingOverBudget(t *testing.T) {
+	files := []*fileutils.FileInfo{
+		{Path: "a.go", TokenCount: 100},
+	}
+
+	got := SuggestDrops(files, 0, DropLargestFirst)
+
+	if len(got.Files) != 0 || got.TokensFreed != 0 {
+		t.Errorf("expected no drops when overBy is 0, got %+v", got)
+	}
+}
@@ -0,0 +1,405 @@
+package prompt
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openprompt/internal/preferences"
+)
+
+// fileCacheEntry is what's persisted per (path, mtime, size) key: enough to
+// reconstruct a file's content and token count without re-reading or
+// re-tokenizing it.
+type fileCacheEntry struct {
+	SHA256     string `json:"sha256"`
+	TokenCount int    `json:"tokenCount"`
+	Size       int64  `json:"size"`
+}
+
+// chunkCacheIndex is the on-disk shape of index.json.
+type chunkCacheIndex struct {
+	Files  map[string]fileCacheEntry `json:"files"`  // "path|mtime|size" -> entry
+	Hashes map[string]int            `json:"hashes"` // sha256 -> tokenCount, for text with no backing file
+}
+
+// ChunkCache is a persistent, content-addressable cache under
+// ~/.openprompt/cache/: content is stored gzip-compressed by its sha256
+// hash, so duplicate or near-duplicate files across different selections
+// share one copy on disk, and an index maps (path, mtime, size) to that
+// hash plus its token count so AssembleFiles, GenerateStream, and
+// EstimateTokens can skip re-reading and re-tokenizing unchanged files.
+type ChunkCache struct {
+	mu       sync.Mutex
+	dir      string
+	index    chunkCacheIndex
+	maxBytes int64
+	hits     int64
+	misses   int64
+}
+
+// NewChunkCache opens (or creates) a ChunkCache rooted at dir, capped at
+// maxBytes of content on disk.
+func NewChunkCache(dir string, maxBytes int64) (*ChunkCache, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "content"), 0755); err != nil {
+		return nil, err
+	}
+
+	c := &ChunkCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		index: chunkCacheIndex{
+			Files:  make(map[string]fileCacheEntry),
+			Hashes: make(map[string]int),
+		},
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "index.json")); err == nil {
+		// A corrupt index just means cold-starting the cache, not failing.
+		_ = json.Unmarshal(data, &c.index)
+	}
+
+	return c, nil
+}
+
+var (
+	defaultChunkCacheOnce sync.Once
+	defaultChunkCache     *ChunkCache
+)
+
+func getDefaultChunkCache() *ChunkCache {
+	defaultChunkCacheOnce.Do(func() {
+		dir, err := preferences.GetCacheDir()
+		if err != nil {
+			dir = filepath.Join(os.TempDir(), "openprompt-cache")
+		}
+
+		cache, err := NewChunkCache(dir, preferences.DefaultCacheMaxBytes)
+		if err != nil {
+			// Fall back to an in-memory-only cache rather than failing
+			// every generate/estimate call because the disk is unwritable.
+			cache = &ChunkCache{
+				maxBytes: preferences.DefaultCacheMaxBytes,
+				index: chunkCacheIndex{
+					Files:  make(map[string]fileCacheEntry),
+					Hashes: make(map[string]int),
+				},
+			}
+		}
+		defaultChunkCache = cache
+	})
+	return defaultChunkCache
+}
+
+// ConfigureCache changes the default cache's size limit, evicting content
+// immediately if the new limit is smaller than what's currently stored.
+// The UI calls this once after loading preferences.CacheMaxBytes.
+func ConfigureCache(maxBytes int64) {
+	cache := getDefaultChunkCache()
+	cache.mu.Lock()
+	cache.maxBytes = maxBytes
+	cache.mu.Unlock()
+	cache.evict()
+}
+
+func fileCacheKey(path string, info os.FileInfo) string {
+	return fmt.Sprintf("%s|%d|%d", path, info.ModTime().UnixNano(), info.Size())
+}
+
+func hashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// lookupFile returns path's cached content and token count if (path,
+// mtime, size) is a known key and its content blob is still on disk.
+func (c *ChunkCache) lookupFile(path string, info os.FileInfo) (content string, tokenCount int, ok bool) {
+	key := fileCacheKey(path, info)
+
+	c.mu.Lock()
+	entry, found := c.index.Files[key]
+	c.mu.Unlock()
+	if !found {
+		c.recordMiss()
+		return "", 0, false
+	}
+
+	data, err := c.readBlob(entry.SHA256)
+	if err != nil {
+		c.recordMiss()
+		return "", 0, false
+	}
+
+	c.recordHit()
+	return string(data), entry.TokenCount, true
+}
+
+// storeFile records path's content and token count under (path, mtime,
+// size), writing the content blob only if this exact content isn't already
+// cached under its hash.
+func (c *ChunkCache) storeFile(path string, info os.FileInfo, content string, tokenCount int) {
+	hash := hashContent(content)
+	if err := c.writeBlob(hash, content); err != nil {
+		return // best-effort: a write failure just means no caching this round
+	}
+
+	key := fileCacheKey(path, info)
+	c.mu.Lock()
+	c.index.Files[key] = fileCacheEntry{SHA256: hash, TokenCount: tokenCount, Size: int64(len(content))}
+	c.index.Hashes[hash] = tokenCount
+	c.mu.Unlock()
+
+	c.persistIndex()
+	c.evict()
+}
+
+// lookupTokenCount returns a cached token count for arbitrary text, keyed
+// by its content hash rather than a file path — EstimateTokens only ever
+// has the text itself, not a file to stat.
+func (c *ChunkCache) lookupTokenCount(text string) (int, bool) {
+	hash := hashContent(text)
+
+	c.mu.Lock()
+	count, ok := c.index.Hashes[hash]
+	c.mu.Unlock()
+
+	if ok {
+		c.recordHit()
+	} else {
+		c.recordMiss()
+	}
+	return count, ok
+}
+
+// storeTokenCount records a token count for arbitrary text under its
+// content hash. It doesn't store a content blob — storeFile is what does
+// that, since it has a path to derive an eviction-worthy blob from.
+func (c *ChunkCache) storeTokenCount(text string, count int) {
+	hash := hashContent(text)
+	c.mu.Lock()
+	c.index.Hashes[hash] = count
+	c.mu.Unlock()
+	c.persistIndex()
+}
+
+func (c *ChunkCache) blobPath(hash string) string {
+	return filepath.Join(c.dir, "content", hash+".gz")
+}
+
+func (c *ChunkCache) readBlob(hash string) ([]byte, error) {
+	if c.dir == "" {
+		return nil, fmt.Errorf("prompt: chunk cache has no backing directory")
+	}
+
+	path := c.blobPath(hash)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(path, now, now) // touch so this blob looks freshly-used for LRU eviction
+	return data, nil
+}
+
+func (c *ChunkCache) writeBlob(hash, content string) error {
+	if c.dir == "" {
+		return fmt.Errorf("prompt: chunk cache has no backing directory")
+	}
+
+	path := c.blobPath(hash)
+	if _, err := os.Stat(path); err == nil {
+		now := time.Now()
+		return os.Chtimes(path, now, now)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	if _, err := gz.Write([]byte(content)); err != nil {
+		gz.Close()
+		return err
+	}
+	return gz.Close()
+}
+
+func (c *ChunkCache) persistIndex() {
+	if c.dir == "" {
+		return
+	}
+
+	c.mu.Lock()
+	data, err := json.MarshalIndent(c.index, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(filepath.Join(c.dir, "index.json"), data, 0644)
+}
+
+// evict removes the least-recently-used content blobs (by file mtime,
+// which every read and write touches) until the cache is back under
+// maxBytes, then drops any index entries that pointed at a removed blob.
+func (c *ChunkCache) evict() {
+	if c.dir == "" {
+		return
+	}
+
+	contentDir := filepath.Join(c.dir, "content")
+	dirEntries, err := os.ReadDir(contentDir)
+	if err != nil {
+		return
+	}
+
+	type blob struct {
+		hash    string
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var blobs []blob
+	var total int64
+	for _, e := range dirEntries {
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		blobs = append(blobs, blob{
+			hash:    strings.TrimSuffix(e.Name(), ".gz"),
+			path:    filepath.Join(contentDir, e.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+		total += info.Size()
+	}
+
+	c.mu.Lock()
+	maxBytes := c.maxBytes
+	c.mu.Unlock()
+	if total <= maxBytes {
+		return
+	}
+
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].modTime.Before(blobs[j].modTime) })
+
+	evicted := make(map[string]bool)
+	for _, b := range blobs {
+		if total <= maxBytes {
+			break
+		}
+		if os.Remove(b.path) == nil {
+			total -= b.size
+			evicted[b.hash] = true
+		}
+	}
+
+	c.mu.Lock()
+	for key, entry := range c.index.Files {
+		if evicted[entry.SHA256] {
+			delete(c.index.Files, key)
+		}
+	}
+	c.mu.Unlock()
+	c.persistIndex()
+}
+
+func (c *ChunkCache) recordHit() {
+	c.mu.Lock()
+	c.hits++
+	c.mu.Unlock()
+}
+
+func (c *ChunkCache) recordMiss() {
+	c.mu.Lock()
+	c.misses++
+	c.mu.Unlock()
+}
+
+// CacheStats summarizes a ChunkCache's effectiveness and disk footprint.
+type CacheStats struct {
+	Hits         int64
+	Misses       int64
+	HitRate      float64 // 0 when there have been no lookups yet
+	Entries      int
+	ContentBytes int64
+}
+
+func (c *ChunkCache) stats() CacheStats {
+	c.mu.Lock()
+	hits, misses := c.hits, c.misses
+	fileEntries := len(c.index.Files)
+	c.mu.Unlock()
+
+	var contentBytes int64
+	if c.dir != "" {
+		if dirEntries, err := os.ReadDir(filepath.Join(c.dir, "content")); err == nil {
+			for _, e := range dirEntries {
+				if info, err := e.Info(); err == nil {
+					contentBytes += info.Size()
+				}
+			}
+		}
+	}
+
+	stats := CacheStats{Hits: hits, Misses: misses, Entries: fileEntries, ContentBytes: contentBytes}
+	if hits+misses > 0 {
+		stats.HitRate = float64(hits) / float64(hits+misses)
+	}
+	return stats
+}
+
+// GetCacheStats reports the default chunk cache's hit rate and disk usage,
+// for the UI status line.
+func GetCacheStats() CacheStats {
+	return getDefaultChunkCache().stats()
+}
+
+// readFileCached returns path's content, consulting the default chunk
+// cache before reading from disk and tokenizing.
+func readFileCached(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	cache := getDefaultChunkCache()
+	if content, _, ok := cache.lookupFile(path, info); ok {
+		return content, nil
+	}
+
+	bufPtr := bufferPool.Get().(*[]byte)
+	content, err := readFileWithBuffer(path, *bufPtr)
+	bufferPool.Put(bufPtr)
+	if err != nil {
+		return "", err
+	}
+
+	tokenCount, _ := countTokensUncached(content)
+	cache.storeFile(path, info, content, tokenCount)
+	return content, nil
+}
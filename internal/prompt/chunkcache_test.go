@@ -0,0 +1,155 @@
+package prompt
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestChunkCacheFileHitAndMiss(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewChunkCache(filepath.Join(dir, "cache"), 1<<20)
+	if err != nil {
+		t.Fatalf("NewChunkCache: %v", err)
+	}
+
+	path := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(path, []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, ok := cache.lookupFile(path, info); ok {
+		t.Fatal("expected a miss before the file has ever been stored")
+	}
+
+	cache.storeFile(path, info, "package a\n", 3)
+
+	content, tokenCount, ok := cache.lookupFile(path, info)
+	if !ok {
+		t.Fatal("expected a hit after storeFile")
+	}
+	if content != "package a\n" {
+		t.Errorf("content = %q, want %q", content, "package a\n")
+	}
+	if tokenCount != 3 {
+		t.Errorf("tokenCount = %d, want 3", tokenCount)
+	}
+
+	stats := cache.stats()
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("stats = %+v, want 1 hit and 1 miss", stats)
+	}
+	if stats.HitRate != 0.5 {
+		t.Errorf("HitRate = %v, want 0.5", stats.HitRate)
+	}
+}
+
+// TestChunkCacheKeyedByMtimeAndSize verifies that rewriting a file with a
+// new size and mtime (at the same path) invalidates the cached entry, since a
+// (path, mtime, size) key is what storeFile/lookupFile use, not path alone.
+func TestChunkCacheKeyedByMtimeAndSize(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewChunkCache(filepath.Join(dir, "cache"), 1<<20)
+	if err != nil {
+		t.Fatalf("NewChunkCache: %v", err)
+	}
+
+	path := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(path, []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info1, _ := os.Stat(path)
+	cache.storeFile(path, info1, "v1", 1)
+
+	// Rewrite with different content and a distinct size, which also moves
+	// mtime forward.
+	if err := os.WriteFile(path, []byte("v2-longer"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info2, _ := os.Stat(path)
+
+	if _, _, ok := cache.lookupFile(path, info2); ok {
+		t.Fatal("expected a miss for the new (mtime, size) key, not the stale entry from the old content")
+	}
+}
+
+func TestChunkCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	// Cap small enough that only one stored blob fits at a time. Content is
+	// random (not a repeated byte) so gzip can't compress either blob down
+	// far enough for both to fit; a realistic file's content wouldn't
+	// compress as losslessly as an all-zero buffer would.
+	cache, err := NewChunkCache(filepath.Join(dir, "cache"), 300)
+	if err != nil {
+		t.Fatalf("NewChunkCache: %v", err)
+	}
+
+	randBytes := func(seed int64, n int) []byte {
+		b := make([]byte, n)
+		rand.New(rand.NewSource(seed)).Read(b)
+		return b
+	}
+
+	pathA := filepath.Join(dir, "a.txt")
+	pathB := filepath.Join(dir, "b.txt")
+	contentA := string(randBytes(1, 200))
+	contentB := string(randBytes(2, 200))
+
+	if err := os.WriteFile(pathA, []byte(contentA), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pathB, []byte(contentB), 0644); err != nil {
+		t.Fatal(err)
+	}
+	infoA, _ := os.Stat(pathA)
+	infoB, _ := os.Stat(pathB)
+
+	cache.storeFile(pathA, infoA, contentA, 1)
+
+	// evict() orders blobs by file mtime, but a's and b's writes can land in
+	// the same mtime tick on a filesystem with coarse timestamp resolution,
+	// making the eviction order a coin flip. Back-date a's blob explicitly so
+	// it's unambiguously the least recently used one, regardless of clock
+	// resolution.
+	aBlob := cache.blobPath(hashContent(contentA))
+	older := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(aBlob, older, older); err != nil {
+		t.Fatal(err)
+	}
+
+	cache.storeFile(pathB, infoB, contentB, 1)
+
+	// Storing b should have evicted a's blob, since a hasn't been touched
+	// since and the combined size exceeds maxBytes.
+	if _, _, ok := cache.lookupFile(pathA, infoA); ok {
+		t.Error("expected a's entry to have been evicted to make room for b")
+	}
+	if _, _, ok := cache.lookupFile(pathB, infoB); !ok {
+		t.Error("expected b's entry to still be cached")
+	}
+}
+
+func TestChunkCacheTokenCountByContentHash(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewChunkCache(filepath.Join(dir, "cache"), 1<<20)
+	if err != nil {
+		t.Fatalf("NewChunkCache: %v", err)
+	}
+
+	if _, ok := cache.lookupTokenCount("some text"); ok {
+		t.Fatal("expected a miss for text never stored")
+	}
+
+	cache.storeTokenCount("some text", 42)
+
+	count, ok := cache.lookupTokenCount("some text")
+	if !ok || count != 42 {
+		t.Errorf("lookupTokenCount = (%d, %v), want (42, true)", count, ok)
+	}
+}
@@ -0,0 +1,94 @@
+package prompt
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/openprompt/internal/fileutils"
+)
+
+// Formatter renders an assembled file set and the user's instructions into
+// the final text handed to an LLM. XML, Markdown, JSON, and plain
+// concatenation each implement this the same way a Tokenizer implementation
+// picks its own encoding (fileutils.Tokenizer).
+type Formatter interface {
+	// Name identifies the formatter, e.g. "markdown", for UI display and
+	// FormatterRegistry lookup.
+	Name() string
+	// Format renders files and instructions into the final prompt text.
+	Format(files []File, instructions string) (string, error)
+}
+
+// FormatterRegistry resolves a Formatter by name, the way callers pick an
+// output format from a UI dropdown.
+type FormatterRegistry struct {
+	mu         sync.RWMutex
+	formatters map[string]Formatter
+}
+
+// DefaultFormatterRegistry is pre-populated with every Formatter this
+// package ships.
+var DefaultFormatterRegistry = NewFormatterRegistry()
+
+// NewFormatterRegistry creates a registry seeded with the built-in
+// formatters.
+func NewFormatterRegistry() *FormatterRegistry {
+	r := &FormatterRegistry{formatters: make(map[string]Formatter)}
+
+	r.Register(xmlFormatter{})
+	r.Register(markdownFormatter{})
+	r.Register(jsonFormatter{})
+	r.Register(concatFormatter{})
+
+	return r
+}
+
+// Register adds or replaces a Formatter under its own Name().
+func (r *FormatterRegistry) Register(f Formatter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.formatters[f.Name()] = f
+}
+
+// Get returns the Formatter registered under name, or an error if none is.
+func (r *FormatterRegistry) Get(name string) (Formatter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	f, ok := r.formatters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown prompt formatter %q", name)
+	}
+	return f, nil
+}
+
+// Names returns every registered formatter name, suitable for populating a
+// UI dropdown.
+func (r *FormatterRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.formatters))
+	for name := range r.formatters {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Generate assembles files via AssembleFiles and renders the result with
+// the named formatter. The returned error (if any) reports files that were skipped
+// rather than aborting the whole prompt, matching AssembleFiles' behavior.
+// source is forwarded to AssembleFiles; pass nil for a plain directory on
+// disk, or a fileutils.Source from fileutils.NewSource for an archive.
+func Generate(files []*fileutils.FileInfo, instructions, baseDir, formatName string, source fileutils.Source) (string, error) {
+	formatter, err := DefaultFormatterRegistry.Get(formatName)
+	if err != nil {
+		return "", err
+	}
+
+	assembled, assembleErr := AssembleFiles(files, baseDir, source)
+
+	out, err := formatter.Format(assembled, instructions)
+	if err != nil {
+		return "", err
+	}
+	return out, assembleErr
+}
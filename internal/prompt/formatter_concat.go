@@ -0,0 +1,32 @@
+package prompt
+
+import "strings"
+
+// concatFormatter renders files as plain "===== path =====" banners
+// followed by their raw content, with no markup at all — the cheapest
+// format to paste somewhere that strips Markdown/XML.
+type concatFormatter struct{}
+
+func (concatFormatter) Name() string { return "concat" }
+
+func (concatFormatter) Format(files []File, instructions string) (string, error) {
+	var b strings.Builder
+
+	if instructions != "" {
+		b.WriteString(instructions)
+		b.WriteString("\n\n")
+	}
+
+	for _, f := range files {
+		b.WriteString("===== ")
+		b.WriteString(f.Path)
+		b.WriteString(" =====\n")
+		b.WriteString(f.Content)
+		if !strings.HasSuffix(f.Content, "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,50 @@
+package prompt
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// chatMessage is a single OpenAI chat-completion message.
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// chatPayload mirrors the body of an OpenAI chat-completions request, so
+// the output can be pasted directly into one.
+type chatPayload struct {
+	Messages []chatMessage `json:"messages"`
+}
+
+// jsonFormatter renders files and instructions as an OpenAI-style
+// {"messages": [...]} document: a system message carrying the
+// instructions, followed by a user message with every file concatenated.
+type jsonFormatter struct{}
+
+func (jsonFormatter) Name() string { return "json" }
+
+func (jsonFormatter) Format(files []File, instructions string) (string, error) {
+	payload := chatPayload{}
+	if instructions != "" {
+		payload.Messages = append(payload.Messages, chatMessage{Role: "system", Content: instructions})
+	}
+
+	var b strings.Builder
+	for _, f := range files {
+		b.WriteString("===== ")
+		b.WriteString(f.Path)
+		b.WriteString(" =====\n")
+		b.WriteString(f.Content)
+		if !strings.HasSuffix(f.Content, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	payload.Messages = append(payload.Messages, chatMessage{Role: "user", Content: b.String()})
+
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,56 @@
+package prompt
+
+import "strings"
+
+// markdownLangByExt maps a file extension to the language tag fenced code
+// blocks use; an extension not listed here is used as the tag verbatim.
+var markdownLangByExt = map[string]string{
+	"py":  "python",
+	"rb":  "ruby",
+	"rs":  "rust",
+	"sh":  "bash",
+	"yml": "yaml",
+	"md":  "markdown",
+	"ts":  "typescript",
+	"tsx": "tsx",
+	"js":  "javascript",
+	"jsx": "jsx",
+}
+
+// markdownFormatter renders the instructions under an "# Instructions"
+// heading, then each file as a fenced code block under "# Files", the
+// format GitHub/most chat UIs render best.
+type markdownFormatter struct{}
+
+func (markdownFormatter) Name() string { return "markdown" }
+
+func (markdownFormatter) Format(files []File, instructions string) (string, error) {
+	var b strings.Builder
+
+	if instructions != "" {
+		b.WriteString("# Instructions\n\n")
+		b.WriteString(instructions)
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString("# Files\n\n")
+	for _, f := range files {
+		lang := markdownLangByExt[f.Type]
+		if lang == "" {
+			lang = f.Type
+		}
+
+		b.WriteString("## ")
+		b.WriteString(f.Path)
+		b.WriteString("\n\n```")
+		b.WriteString(lang)
+		b.WriteString("\n")
+		b.WriteString(f.Content)
+		if !strings.HasSuffix(f.Content, "\n") {
+			b.WriteString("\n")
+		}
+		b.WriteString("```\n\n")
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,105 @@
+package prompt
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFormattersAgainstFixedInput(t *testing.T) {
+	files := []File{
+		{Path: "main.go", Type: "go", Content: "package main\n"},
+		{Path: "README.md", Type: "md", Content: "# Title"},
+	}
+	instructions := "Summarize these files."
+
+	tests := []struct {
+		name      string
+		formatter Formatter
+		check     func(t *testing.T, out string)
+	}{
+		{
+			name:      "xml",
+			formatter: xmlFormatter{},
+			check: func(t *testing.T, out string) {
+				if !strings.HasPrefix(out, `<?xml`) {
+					t.Errorf("expected an XML header, got %q", out)
+				}
+				if !strings.Contains(out, `path="main.go"`) {
+					t.Errorf("expected main.go's path attribute, got %s", out)
+				}
+				if !strings.Contains(out, instructions) {
+					t.Errorf("expected instructions in output, got %s", out)
+				}
+			},
+		},
+		{
+			name:      "markdown",
+			formatter: markdownFormatter{},
+			check: func(t *testing.T, out string) {
+				if !strings.Contains(out, "# Instructions") {
+					t.Errorf("expected an Instructions section, got %s", out)
+				}
+				if !strings.Contains(out, "```go\npackage main") {
+					t.Errorf("expected main.go fenced with the go language tag, got %s", out)
+				}
+				if !strings.Contains(out, "```markdown\n# Title") {
+					t.Errorf("expected README.md fenced with the markdown language tag (via markdownLangByExt), got %s", out)
+				}
+			},
+		},
+		{
+			name:      "json",
+			formatter: jsonFormatter{},
+			check: func(t *testing.T, out string) {
+				var payload chatPayload
+				if err := json.Unmarshal([]byte(out), &payload); err != nil {
+					t.Fatalf("output isn't valid JSON: %v", err)
+				}
+				if len(payload.Messages) != 2 {
+					t.Fatalf("expected a system + user message, got %d", len(payload.Messages))
+				}
+				if payload.Messages[0].Role != "system" || payload.Messages[0].Content != instructions {
+					t.Errorf("expected the system message to carry instructions verbatim, got %+v", payload.Messages[0])
+				}
+				if !strings.Contains(payload.Messages[1].Content, "===== main.go =====") {
+					t.Errorf("expected the user message to contain main.go's banner, got %s", payload.Messages[1].Content)
+				}
+			},
+		},
+		{
+			name:      "concat",
+			formatter: concatFormatter{},
+			check: func(t *testing.T, out string) {
+				if !strings.HasPrefix(out, instructions) {
+					t.Errorf("expected instructions first with no markup, got %s", out)
+				}
+				if !strings.Contains(out, "===== main.go =====\npackage main") {
+					t.Errorf("expected main.go's banner and content, got %s", out)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := tt.formatter.Format(files, instructions)
+			if err != nil {
+				t.Fatalf("Format: %v", err)
+			}
+			tt.check(t, out)
+		})
+	}
+}
+
+func TestFormatterRegistryResolvesEveryBuiltin(t *testing.T) {
+	for _, name := range []string{"xml", "markdown", "json", "concat"} {
+		if _, err := DefaultFormatterRegistry.Get(name); err != nil {
+			t.Errorf("Get(%q): %v", name, err)
+		}
+	}
+
+	if _, err := DefaultFormatterRegistry.Get("nope"); err == nil {
+		t.Error("expected an error for an unregistered formatter name")
+	}
+}
@@ -0,0 +1,42 @@
+package prompt
+
+import (
+	"encoding/xml"
+
+	"github.com/openprompt/internal/fileutils"
+)
+
+// Prompt represents an XML prompt for an LLM
+type Prompt struct {
+	XMLName      xml.Name `xml:"prompt"`
+	Files        []File   `xml:"files>file"`
+	Instructions string   `xml:"instructions"`
+}
+
+// xmlFormatter renders files and instructions as the original <prompt>
+// envelope, kept as the default format for compatibility with prompts
+// generated before the other formats existed.
+type xmlFormatter struct{}
+
+func (xmlFormatter) Name() string { return "xml" }
+
+func (xmlFormatter) Format(files []File, instructions string) (string, error) {
+	prompt := Prompt{
+		Files:        files,
+		Instructions: instructions,
+	}
+
+	xmlData, err := xml.MarshalIndent(prompt, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return xml.Header + string(xmlData), nil
+}
+
+// GenerateXML generates an XML prompt from a list of files. It's a thin
+// wrapper over Generate kept for callers that only ever want the XML
+// format.
+func GenerateXML(files []*fileutils.FileInfo, instructions string, baseDir string, source fileutils.Source) (string, error) {
+	return Generate(files, instructions, baseDir, "xml", source)
+}
@@ -2,7 +2,6 @@ package prompt
 
 import (
 	"bufio"
-	"encoding/xml"
 	"fmt"
 	"io"
 	"os"
@@ -14,17 +13,12 @@ import (
 
 	"github.com/openprompt/internal/fileutils"
 	"github.com/pkoukk/tiktoken-go"
-	gitignore "github.com/sabhiram/go-gitignore"
 )
 
-// Prompt represents an XML prompt for an LLM
-type Prompt struct {
-	XMLName      xml.Name `xml:"prompt"`
-	Files        []File   `xml:"files>file"`
-	Instructions string   `xml:"instructions"`
-}
-
-// File represents a file in the XML prompt
+// File is a single file's content plus the metadata every Formatter needs to
+// render it: its path relative to the base directory and its extension
+// (without the leading dot), used for things like Markdown's fenced-block
+// language tag.
 type File struct {
 	Path    string `xml:"path,attr"`
 	Type    string `xml:"type,attr"`
@@ -46,15 +40,23 @@ var bufferPool = sync.Pool{
 	},
 }
 
-// GenerateXML generates an XML prompt from a list of files
-func GenerateXML(files []*fileutils.FileInfo, instructions string, baseDir string) (string, error) {
+// AssembleFiles reads every non-directory entry in files relative to
+// baseDir, filtering out .DS_Store and anything already flagged
+// fileutils.FileInfo.Ignored by the walk that produced files, using a
+// worker pool and pooled buffers so the I/O-bound reads run concurrently.
+// Every Formatter builds its output from this same slice, so the
+// concurrency and filtering logic lives here exactly once.
+//
+// source selects where content is actually read from: nil or
+// fileutils.OSSource (the common case) reads baseDir straight off disk
+// through the persistent chunk cache; any other fileutils.Source — an
+// archive opened via fileutils.NewSource — is read through Source.Open
+// instead, since an
+// archive entry has no (path, mtime) pair for that cache to key on.
+func AssembleFiles(files []*fileutils.FileInfo, baseDir string, source fileutils.Source) ([]File, error) {
 	// Start with maximum parallelism
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
-	prompt := Prompt{
-		Instructions: instructions,
-	}
-
 	// Count how many files we need to process (non-directories)
 	fileCount := 0
 	for _, file := range files {
@@ -64,12 +66,7 @@ func GenerateXML(files []*fileutils.FileInfo, instructions string, baseDir strin
 	}
 
 	if fileCount == 0 {
-		// No files to process
-		xmlData, err := xml.MarshalIndent(prompt, "", "  ")
-		if err != nil {
-			return "", err
-		}
-		return xml.Header + string(xmlData), nil
+		return nil, nil
 	}
 
 	// Create a channel to collect results
@@ -106,13 +103,13 @@ func GenerateXML(files []*fileutils.FileInfo, instructions string, baseDir strin
 					continue
 				}
 
-				// Check if file should be ignored based on .gitignore
-				gitIgnorePath := filepath.Join(baseDir, ".gitignore")
-				if _, err := os.Stat(gitIgnorePath); err == nil {
-					ignore, err := gitignore.CompileIgnoreFile(gitIgnorePath)
-					if err == nil && ignore.MatchesPath(relPath) {
-						continue
-					}
+				// fileInfo.Ignored was already decided once for the whole
+				// tree by fileutils.IgnoreMatcher during the walk (nested
+				// .gitignore files, negation, and the global ignore list
+				// all considered), so there's no need to re-derive it here
+				// by re-parsing a flat .gitignore on every file.
+				if fileInfo.Ignored {
+					continue
 				}
 
 				// Get file type
@@ -121,16 +118,11 @@ func GenerateXML(files []*fileutils.FileInfo, instructions string, baseDir strin
 					fileType = fileType[1:] // Remove leading dot
 				}
 
-				// Get a buffer from the pool
-				bufPtr := bufferPool.Get().(*[]byte)
-				buffer := *bufPtr
-
-				// Read file content using buffered I/O for better performance
-				content, err := readFileWithBuffer(fileInfo.Path, buffer)
+				// Read file content, consulting the chunk cache first (for
+				// a plain directory) so an unchanged file isn't re-read on
+				// every regeneration
+				content, err := readFileFor(fileInfo.Path, source)
 				if err != nil {
-					// Return the buffer to the pool
-					bufferPool.Put(bufPtr)
-
 					resultChan <- fileReadResult{
 						err: fmt.Errorf("error reading file %s: %v", fileInfo.Path, err),
 					}
@@ -146,9 +138,6 @@ func GenerateXML(files []*fileutils.FileInfo, instructions string, baseDir strin
 					},
 					err: nil,
 				}
-
-				// Return the buffer to the pool
-				bufferPool.Put(bufPtr)
 			}
 		}()
 	}
@@ -172,37 +161,41 @@ func GenerateXML(files []*fileutils.FileInfo, instructions string, baseDir strin
 	var processedCount int32 = 0
 
 	// Pre-allocate the slice with the expected capacity
-	prompt.Files = make([]File, 0, fileCount)
+	assembled := make([]File, 0, fileCount)
 
 	// Process results as they come in
 	for result := range resultChan {
 		if result.err != nil {
 			lastError = result.err
-			fmt.Printf("Error: %v\n", result.err)
 			continue
 		}
 
-		prompt.Files = append(prompt.Files, result.file)
+		assembled = append(assembled, result.file)
 		atomic.AddInt32(&processedCount, 1)
-
-		// Debug output (less frequent to reduce overhead)
-		if processedCount%500 == 0 {
-			fmt.Printf("Processed %d/%d files\n", processedCount, fileCount)
-		}
 	}
 
-	fmt.Printf("Total files processed: %d/%d\n", processedCount, fileCount)
+	return assembled, lastError
+}
+
+// readFileFor reads path's content through source, or — when source is nil
+// or fileutils.OSSource — through the persistent chunk cache, which is the
+// fast path for the common case of a real directory on disk.
+func readFileFor(path string, source fileutils.Source) (string, error) {
+	if source == nil || source == fileutils.OSSource {
+		return readFileCached(path)
+	}
 
-	// Marshal to XML
-	xmlData, err := xml.MarshalIndent(prompt, "", "  ")
+	rc, err := source.Open(path)
 	if err != nil {
 		return "", err
 	}
+	defer rc.Close()
 
-	// Add XML header
-	xmlString := xml.Header + string(xmlData)
-
-	return xmlString, lastError
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
 }
 
 // readFileWithBuffer reads a file using a provided buffer for better performance
@@ -243,8 +236,25 @@ func readFileWithBuffer(filePath string, buffer []byte) (string, error) {
 	return string(content), nil
 }
 
-// EstimateTokens estimates the number of tokens in a string
+// EstimateTokens estimates the number of tokens in a string, consulting the
+// chunk cache by content hash first so repeatedly estimating the same
+// unchanged text doesn't pay tiktoken's cost again.
 func EstimateTokens(text string) (int, error) {
+	cache := getDefaultChunkCache()
+	if count, ok := cache.lookupTokenCount(text); ok {
+		return count, nil
+	}
+
+	count, err := countTokensUncached(text)
+	if err == nil {
+		cache.storeTokenCount(text, count)
+	}
+	return count, err
+}
+
+// countTokensUncached does the actual tiktoken tokenization EstimateTokens
+// caches the result of.
+func countTokensUncached(text string) (int, error) {
 	// Use tiktoken-go for accurate tokenization
 	tk, err := tiktoken.GetEncoding("cl100k_base") // For GPT-3/4
 	if err != nil {
@@ -0,0 +1,133 @@
+package prompt
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/openprompt/internal/fileutils"
+)
+
+// Stage identifies which step of GenerateStream a Progress event describes.
+type Stage string
+
+const (
+	StageReading   Stage = "reading"   // a file's contents are being read and written out
+	StageCancelled Stage = "cancelled" // ctx was cancelled before the stream finished
+	StageDone      Stage = "done"      // the stream completed successfully
+)
+
+// Progress reports incremental status for GenerateStream, one event per
+// file plus a final StageDone (or StageCancelled) event.
+type Progress struct {
+	Path       string
+	BytesRead  int64
+	FilesDone  int
+	FilesTotal int
+	Stage      Stage
+	Err        error // non-nil if Path failed to read; the stream continues past it
+}
+
+// GenerateStream renders files and instructions as the same <prompt> XML
+// envelope as GenerateXML, but writes to w incrementally as each file is
+// read instead of buffering every file's content in memory first, and
+// reports progress on the returned channel as it goes. The channel is
+// closed once the stream finishes or is cancelled via ctx.
+//
+// Unlike AssembleFiles, files are read one at a time in the order given rather
+// than through a worker pool, since everything funnels through the single
+// io.Writer anyway — the win here is bounded memory (one file's content
+// at a time, not the whole tree's) and real progress, not throughput.
+//
+// source is forwarded the same way AssembleFiles uses it: nil or
+// fileutils.OSSource reads baseDir off disk through the persistent chunk
+// cache, any other fileutils.Source reads through Source.Open instead (e.g.
+// an archive from fileutils.NewSource).
+func GenerateStream(ctx context.Context, files []*fileutils.FileInfo, instructions, baseDir string, source fileutils.Source, w io.Writer) (<-chan Progress, error) {
+	var toRead []*fileutils.FileInfo
+	for _, f := range files {
+		if f.IsDir {
+			continue
+		}
+		// f.Ignored was already decided once for the whole tree by
+		// fileutils.IgnoreMatcher during the walk that produced f (nested
+		// .gitignore files, negation, and the global ignore list all
+		// considered); no need to re-derive it by re-parsing baseDir's
+		// .gitignore here.
+		if f.Ignored {
+			continue
+		}
+		relPath, err := filepath.Rel(baseDir, f.Path)
+		if err != nil {
+			relPath = f.Path
+		}
+		if strings.HasSuffix(relPath, ".DS_Store") {
+			continue
+		}
+		toRead = append(toRead, f)
+	}
+
+	progressCh := make(chan Progress, 8)
+
+	go func() {
+		defer close(progressCh)
+
+		enc := xml.NewEncoder(w)
+		enc.Indent("", "  ")
+
+		io.WriteString(w, xml.Header)
+		enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: "prompt"}})
+		enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: "files"}})
+
+		for i, f := range toRead {
+			if ctx.Err() != nil {
+				progressCh <- Progress{FilesDone: i, FilesTotal: len(toRead), Stage: StageCancelled, Err: ctx.Err()}
+				return
+			}
+
+			relPath, err := filepath.Rel(baseDir, f.Path)
+			if err != nil {
+				relPath = f.Path
+			}
+			fileType := strings.TrimPrefix(filepath.Ext(f.Path), ".")
+
+			content, err := readFileFor(f.Path, source)
+			if err != nil {
+				progressCh <- Progress{Path: relPath, FilesDone: i, FilesTotal: len(toRead), Stage: StageReading, Err: err}
+				continue
+			}
+
+			fileStart := xml.StartElement{Name: xml.Name{Local: "file"}, Attr: []xml.Attr{
+				{Name: xml.Name{Local: "path"}, Value: relPath},
+				{Name: xml.Name{Local: "type"}, Value: fileType},
+			}}
+			enc.EncodeToken(fileStart)
+			enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: "filecontents"}})
+			enc.EncodeToken(xml.CharData(content))
+			enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "filecontents"}})
+			enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "file"}})
+			enc.Flush()
+
+			progressCh <- Progress{
+				Path:       relPath,
+				BytesRead:  int64(len(content)),
+				FilesDone:  i + 1,
+				FilesTotal: len(toRead),
+				Stage:      StageReading,
+			}
+		}
+
+		enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "files"}})
+		enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: "instructions"}})
+		enc.EncodeToken(xml.CharData(instructions))
+		enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "instructions"}})
+		enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "prompt"}})
+		enc.Flush()
+
+		progressCh <- Progress{FilesDone: len(toRead), FilesTotal: len(toRead), Stage: StageDone}
+	}()
+
+	return progressCh, nil
+}
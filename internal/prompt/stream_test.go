@@ -0,0 +1,124 @@
+package prompt
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openprompt/internal/fileutils"
+)
+
+// directSource reads straight off disk via os.Open, bypassing the package's
+// global chunk cache — GenerateStream only skips the cache for a non-nil,
+// non-OSSource fileutils.Source, which is exactly what a test needs to stay
+// isolated from the real ~/.openprompt/cache.
+type directSource struct{}
+
+func (directSource) Open(path string) (io.ReadCloser, error) { return os.Open(path) }
+func (directSource) Walk(ctx context.Context, root string, filters fileutils.FileFilters) <-chan fileutils.WalkResult {
+	panic("not used by GenerateStream")
+}
+
+func writeStreamTestFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerateStreamProducesWellFormedXML(t *testing.T) {
+	dir := t.TempDir()
+	writeStreamTestFile(t, filepath.Join(dir, "a.go"), "package a\n")
+	writeStreamTestFile(t, filepath.Join(dir, "b.txt"), "hello")
+
+	files := []*fileutils.FileInfo{
+		{Path: filepath.Join(dir, "a.go")},
+		{Path: filepath.Join(dir, "b.txt")},
+	}
+
+	var buf strings.Builder
+	progressCh, err := GenerateStream(context.Background(), files, "do the thing", dir, directSource{}, &buf)
+	if err != nil {
+		t.Fatalf("GenerateStream: %v", err)
+	}
+
+	var last Progress
+	var events int
+	for p := range progressCh {
+		events++
+		last = p
+	}
+
+	if events != len(files)+1 {
+		t.Fatalf("expected %d per-file events plus a final Done event, got %d", len(files)+1, events)
+	}
+	if last.Stage != StageDone {
+		t.Errorf("expected the final event to be StageDone, got %q", last.Stage)
+	}
+	if last.FilesDone != len(files) || last.FilesTotal != len(files) {
+		t.Errorf("expected FilesDone/FilesTotal to both be %d on completion, got %+v", len(files), last)
+	}
+
+	var prompt Prompt
+	if err := xml.Unmarshal([]byte(buf.String()), &prompt); err != nil {
+		t.Fatalf("output isn't well-formed XML: %v\n%s", err, buf.String())
+	}
+	if len(prompt.Files) != 2 {
+		t.Fatalf("expected 2 files in the decoded prompt, got %d", len(prompt.Files))
+	}
+	if prompt.Instructions != "do the thing" {
+		t.Errorf("Instructions = %q, want %q", prompt.Instructions, "do the thing")
+	}
+}
+
+func TestGenerateStreamCancellation(t *testing.T) {
+	dir := t.TempDir()
+	var files []*fileutils.FileInfo
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("file%d.txt", i)
+		writeStreamTestFile(t, filepath.Join(dir, name), strings.Repeat("x", 4096))
+		files = append(files, &fileutils.FileInfo{Path: filepath.Join(dir, name)})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var buf strings.Builder
+	progressCh, err := GenerateStream(ctx, files, "", dir, directSource{}, &buf)
+	if err != nil {
+		t.Fatalf("GenerateStream: %v", err)
+	}
+
+	// Cancel as soon as the first file has been reported, well before the
+	// stream would otherwise finish.
+	var sawCancelled bool
+	var eventsAfterCancel int
+	for p := range progressCh {
+		if p.FilesDone == 1 && p.Stage == StageReading {
+			cancel()
+		}
+		if p.Stage == StageCancelled {
+			sawCancelled = true
+		}
+		eventsAfterCancel++
+	}
+
+	if !sawCancelled {
+		t.Fatal("expected a StageCancelled event after ctx was cancelled")
+	}
+	if eventsAfterCancel >= len(files)+1 {
+		t.Errorf("expected the stream to stop well before processing all %d files, got %d events", len(files), eventsAfterCancel)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("ctx was never observed as cancelled")
+	}
+}
@@ -1,16 +1,22 @@
 package ui
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
-	
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/widget"
-	
+
 	"github.com/openprompt/internal/fileutils"
 	"github.com/openprompt/internal/preferences"
 	"github.com/openprompt/internal/prompt"
@@ -21,88 +27,132 @@ type App struct {
 	window     fyne.Window
 	fileTree   *FileTreeWidget
 	currentDir string
-	
+
 	// Prompt controls
 	instructionsEntry *widget.Entry
-	
+
 	// Token estimation
 	tokenEstimation *widget.Label
-	
-	// Generated XML prompt
-	xmlPrompt string
-	
+
+	// Chunk cache hit-rate status line
+	cacheStatus *widget.Label
+
+	// Generated prompt, in whichever format was last selected
+	generatedPrompt string
+
 	// Preferences
 	prefs *preferences.Preferences
+
+	// Hidden-file visibility, toggled live from the file tree toolbar
+	hiddenFilesPolicy fileutils.HiddenPolicy
+
+	// Whether to descend into directory symlinks, toggled live from the
+	// file tree toolbar
+	followSymlinks bool
+
+	// Tokenizer selection, toggled live from the file tree toolbar
+	tokenizerRegistry *fileutils.TokenizerRegistry
+	selectedTokenizer string
+
+	// Output format selection, chosen from the dropdown next to the
+	// generate button and persisted across runs.
+	formatterRegistry *prompt.FormatterRegistry
+	selectedFormat    string
+
+	// Token budget: which model to tokenize selected files against, the
+	// entry holding the user's token limit, and the budget itself (rebuilt
+	// whenever the model changes, since counts aren't comparable across
+	// encodings).
+	selectedModel string
+	tokenBudget   *prompt.TokenBudget
+	limitEntry    *widget.Entry
 }
 
 func NewApp() *App {
 	return &App{
-		fyneApp: app.New(),
+		fyneApp:           app.New(),
+		tokenizerRegistry: fileutils.DefaultTokenizerRegistry,
+		selectedTokenizer: "heuristic",
+		formatterRegistry: prompt.DefaultFormatterRegistry,
+		selectedFormat:    "xml",
+		selectedModel:     prompt.DefaultModel,
+		tokenBudget:       prompt.NewTokenBudget(prompt.DefaultModel),
 	}
 }
 
 func (a *App) Run() {
 	a.window = a.fyneApp.NewWindow("OpenPrompt - LLM File Prompt Generator")
 	a.window.Resize(fyne.NewSize(1200, 800)) // Larger window size
-	
+
 	// Load preferences
 	var err error
 	a.prefs, err = preferences.Load()
 	if err != nil {
 		dialog.ShowError(err, a.window)
 	}
-	
+	if a.prefs != nil && a.prefs.GetLastFormat() != "" {
+		a.selectedFormat = a.prefs.GetLastFormat()
+	}
+	if a.prefs != nil {
+		prompt.ConfigureCache(a.prefs.GetCacheMaxBytes())
+	}
+	if a.prefs != nil && a.prefs.GetLastModel() != "" {
+		a.selectedModel = a.prefs.GetLastModel()
+		a.tokenBudget = prompt.NewTokenBudget(a.selectedModel)
+	}
+
 	// Create file tree
 	fileTree := a.createFileTree()
-	
+
 	// Create prompt controls
 	promptControls := a.createPromptControls()
-	
+
 	// Create token estimation and clipboard controls
 	actionControls := a.createActionControls()
-	
+
 	// Create directory selection and refresh buttons
 	dirButton := widget.NewButton("Select Directory", func() {
 		a.selectDirectory()
 	})
-	
+
 	refreshButton := widget.NewButton("Refresh File Tree", func() {
 		if a.currentDir == "" {
 			dialog.ShowInformation("Error", "Please select a directory first", a.window)
 			return
 		}
-		
+
 		// Force rebuild the file tree
 		fmt.Println("Manually refreshing file tree for:", a.currentDir)
 		a.applyFilters()
 	})
-	
+
 	// Layout the UI
 	dirButtons := container.NewHBox(
 		dirButton,
 		refreshButton,
 	)
-	
+
 	rightPanel := container.NewVBox(
 		dirButtons,
 		promptControls,
 		actionControls,
 	)
-	
+
 	// Create a container that will fill the available space
 	fileTreeContainer := container.NewMax(fileTree)
-	
+
 	// Add a border to make the file tree more visible
 	fileTreeWithBorder := container.NewBorder(nil, nil, nil, nil, fileTreeContainer)
-	
+
 	content := container.NewHSplit(
 		fileTreeWithBorder,
 		rightPanel,
 	)
 	content.SetOffset(0.3) // 30% for file tree, 70% for controls
-	
+
 	a.window.SetContent(content)
-	
+	a.window.SetMainMenu(a.createMainMenu())
+
 	// Load last directory if available
 	lastDir := a.prefs.GetLastDirectory()
 	if lastDir != "" {
@@ -110,37 +160,137 @@ func (a *App) Run() {
 		if info, err := os.Stat(lastDir); err == nil && info.IsDir() {
 			fmt.Println("Loading last directory:", lastDir)
 			a.currentDir = lastDir
-			
+
 			// Apply filters with a slight delay to ensure the UI is fully initialized
 			go func() {
 				// Small delay to ensure UI is ready
 				time.Sleep(200 * time.Millisecond)
-				
+
 				// Apply filters in the main thread
 				a.window.Canvas().Refresh(a.fileTree)
 				a.applyFilters()
 			}()
 		}
 	}
-	
+
 	// Set up window close event to save preferences
 	a.window.SetOnClosed(func() {
 		if a.prefs != nil {
 			a.prefs.Save()
 		}
 	})
-	
+
 	a.window.ShowAndRun()
 }
 
+// createMainMenu builds the window's menu bar, currently just the preset
+// export/import actions described in the Preset doc comment.
+func (a *App) createMainMenu() *fyne.MainMenu {
+	exportItem := fyne.NewMenuItem("Export Preset...", func() {
+		a.exportPreset()
+	})
+	importItem := fyne.NewMenuItem("Import Preset...", func() {
+		a.importPreset()
+	})
+	presetsMenu := fyne.NewMenu("Presets", exportItem, importItem)
+	return fyne.NewMainMenu(presetsMenu)
+}
+
+func (a *App) exportPreset() {
+	if a.currentDir == "" {
+		dialog.ShowInformation("Error", "Please select a directory first", a.window)
+		return
+	}
+
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		path := writer.URI().Path()
+		if err := a.fileTree.ExportPreset(path); err != nil {
+			dialog.ShowError(err, a.window)
+			return
+		}
+		dialog.ShowInformation("Preset Exported", "Saved preset to "+path, a.window)
+	}, a.window)
+	save.SetFileName(".repoprompt.json")
+	save.Show()
+}
+
+func (a *App) importPreset() {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		reader.Close()
+
+		path := reader.URI().Path()
+		loadErr := a.fileTree.ImportPreset(path, func(result fileutils.ApplyResult) {
+			fyne.Do(func() {
+				a.updateTokenEstimation()
+				msg := fmt.Sprintf("Selected %d file(s) from the preset.", len(result.Applied))
+				if len(result.Missing) > 0 {
+					msg += fmt.Sprintf(" %d path(s) from the preset no longer exist: %v", len(result.Missing), result.Missing)
+				}
+				dialog.ShowInformation("Preset Imported", msg, a.window)
+			})
+		})
+		if loadErr != nil {
+			dialog.ShowError(loadErr, a.window)
+			return
+		}
+
+		a.currentDir = a.fileTree.currentDir
+		if a.prefs != nil {
+			a.prefs.SetLastDirectory(a.currentDir)
+			a.prefs.Save()
+		}
+	}, a.window)
+	open.Show()
+}
+
 func (a *App) createFileTree() fyne.CanvasObject {
 	a.fileTree = NewFileTreeWidget(func() {
 		// When selection changes, update token estimation
 		a.updateTokenEstimation()
 	})
-	
+
+	showHiddenCheck := widget.NewCheck("Show hidden files", func(checked bool) {
+		if checked {
+			a.hiddenFilesPolicy = fileutils.HiddenInclude
+		} else {
+			a.hiddenFilesPolicy = fileutils.HiddenExclude
+		}
+
+		// Re-filter the already-loaded directory without prompting the user again
+		if a.currentDir != "" {
+			a.applyFilters()
+		}
+	})
+	showHiddenCheck.Checked = true
+
+	followSymlinksCheck := widget.NewCheck("Follow symlinks", func(checked bool) {
+		a.followSymlinks = checked
+		if a.currentDir != "" {
+			a.applyFilters()
+		}
+	})
+
+	tokenizerSelect := widget.NewSelect(a.tokenizerRegistry.Names(), func(name string) {
+		a.selectedTokenizer = name
+		if a.currentDir != "" {
+			a.applyFilters()
+		}
+	})
+	tokenizerSelect.SetSelected(a.selectedTokenizer)
+
+	toolbar := container.NewHBox(showHiddenCheck, followSymlinksCheck, widget.NewLabel("Tokenizer:"), tokenizerSelect)
+
 	return container.NewVBox(
 		widget.NewLabel("File Tree (select files to include)"),
+		toolbar,
 		container.NewBorder(nil, nil, nil, nil, a.fileTree),
 	)
 }
@@ -156,7 +306,7 @@ func (a *App) createPromptControls() fyne.CanvasObject {
 	a.instructionsEntry.OnChanged = func(text string) {
 		a.updateTokenEstimation()
 	}
-	
+
 	return container.NewVBox(
 		widget.NewCard("LLM Instructions", "", container.NewVBox(
 			instructionsLabel,
@@ -168,105 +318,160 @@ func (a *App) createPromptControls() fyne.CanvasObject {
 func (a *App) createActionControls() fyne.CanvasObject {
 	// Token estimation
 	a.tokenEstimation = widget.NewLabel("Estimated Tokens: 0")
-	
+
+	// Chunk cache hit-rate, refreshed after every generate/preview
+	a.cacheStatus = widget.NewLabel("Cache: --")
+
 	// Model token limit
 	limitLabel := widget.NewLabel("Model Token Limit:")
-	limitEntry := widget.NewEntry()
-	limitEntry.SetText("8192") // Default for GPT-4
-	
+	a.limitEntry = widget.NewEntry()
+	a.limitEntry.SetText("8192") // Default for GPT-4
+	a.limitEntry.OnChanged = func(string) {
+		a.updateTokenEstimation()
+	}
+
+	// Model selection, used to tokenize accurately and persisted via
+	// preferences once changed
+	modelLabel := widget.NewLabel("Model:")
+	modelSelect := widget.NewSelect(prompt.ModelNames(), func(name string) {
+		a.selectedModel = name
+		a.tokenBudget = prompt.NewTokenBudget(name)
+		if a.prefs != nil {
+			a.prefs.SetLastModel(name)
+			a.prefs.Save()
+		}
+		a.updateTokenEstimation()
+	})
+	modelSelect.SetSelected(a.selectedModel)
+
+	// Output format selection, persisted via preferences once changed
+	formatLabel := widget.NewLabel("Format:")
+	formatSelect := widget.NewSelect(a.formatterRegistry.Names(), func(name string) {
+		a.selectedFormat = name
+		if a.prefs != nil {
+			a.prefs.SetLastFormat(name)
+			a.prefs.Save()
+		}
+	})
+	formatSelect.SetSelected(a.selectedFormat)
+
 	// Generate and copy button
 	generateButton := widget.NewButton("Generate & Copy to Clipboard", func() {
 		a.generateAndCopy()
 	})
-	
-	// Preview XML button
-	previewButton := widget.NewButton("Preview XML", func() {
+
+	// Preview button
+	previewButton := widget.NewButton("Preview Prompt", func() {
 		a.previewXML()
 	})
-	
+
 	return container.NewVBox(
 		widget.NewCard("Actions", "", container.NewVBox(
 			a.tokenEstimation,
-			container.NewHBox(limitLabel, limitEntry),
+			container.NewHBox(limitLabel, a.limitEntry),
+			container.NewHBox(modelLabel, modelSelect),
+			container.NewHBox(formatLabel, formatSelect),
 			generateButton,
 			previewButton,
+			a.cacheStatus,
 		)),
 	)
 }
 
+// updateCacheStatus refreshes the cache hit-rate status line from
+// prompt.GetCacheStats.
+func (a *App) updateCacheStatus() {
+	stats := prompt.GetCacheStats()
+	a.cacheStatus.SetText(fmt.Sprintf("Cache: %.0f%% hit rate (%d entries, %sB on disk)",
+		stats.HitRate*100, stats.Entries, fileutils.FormatTokenCount(int(stats.ContentBytes))))
+}
+
 func (a *App) selectDirectory() {
 	// Create a dialog with both browse and manual entry options
 	content := container.NewVBox()
-	
+
 	// Manual path entry
-	pathLabel := widget.NewLabel("Enter Directory Path:")
+	pathLabel := widget.NewLabel("Enter Directory or Archive Path:")
 	pathEntry := widget.NewEntry()
-	pathEntry.SetPlaceHolder("/path/to/directory")
-	
+	pathEntry.SetPlaceHolder("/path/to/directory or archive.zip")
+
 	// Set current directory if available
 	if a.currentDir != "" {
 		pathEntry.SetText(a.currentDir)
 	}
-	
+
 	// Create the dialog first so we can reference it
 	dirDialog := dialog.NewCustom("Select Directory", "Cancel", content, a.window)
-	
+
 	// Buttons
 	browseButton := widget.NewButton("Browse...", func() {
 		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
 			if err != nil || uri == nil {
 				return
 			}
-			
+
 			pathEntry.SetText(uri.Path())
 		}, a.window)
 	})
-	
+
+	browseArchiveButton := widget.NewButton("Browse Archive...", func() {
+		open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+			pathEntry.SetText(reader.URI().Path())
+		}, a.window)
+		open.SetFilter(storage.NewExtensionFileFilter([]string{".zip", ".tar", ".tar.gz", ".tgz", ".tar.bz2"}))
+		open.Show()
+	})
+
 	confirmButton := widget.NewButton("Confirm", func() {
 		path := pathEntry.Text
 		if path == "" {
-			dialog.ShowInformation("Error", "Please enter a directory path", a.window)
+			dialog.ShowInformation("Error", "Please enter a directory or archive path", a.window)
 			return
 		}
-		
-		// Check if directory exists
+
+		// Check the path exists and is either a directory or a recognized archive
 		info, err := os.Stat(path)
-		if err != nil || !info.IsDir() {
-			dialog.ShowInformation("Error", "Invalid directory path", a.window)
+		if err != nil || (!info.IsDir() && !fileutils.IsArchivePath(path)) {
+			dialog.ShowInformation("Error", "Invalid directory or archive path", a.window)
 			return
 		}
-		
+
 		// Close the dialog first
 		dirDialog.Hide()
-		
+
 		// Set current directory
 		a.currentDir = path
-		
+
 		// Save to preferences
 		if a.prefs != nil {
 			a.prefs.SetLastDirectory(path)
 			a.prefs.Save()
 		}
-		
+
 		// Apply filters with a slight delay to ensure the dialog is fully closed
 		go func() {
 			// Small delay to ensure dialog is closed
 			time.Sleep(100 * time.Millisecond)
-			
+
 			fmt.Println("Loading directory:", path)
 			a.applyFilters()
 		}()
 	})
-	
+
 	// Layout
 	content.Add(pathLabel)
 	content.Add(pathEntry)
 	content.Add(container.NewHBox(
 		browseButton,
+		browseArchiveButton,
 		widget.NewLabel(""), // Spacer
 		confirmButton,
 	))
-	
+
 	// Show dialog
 	dirDialog.Show()
 }
@@ -276,150 +481,183 @@ func (a *App) applyFilters() {
 		dialog.ShowInformation("Error", "Please select a directory first", a.window)
 		return
 	}
-	
+
 	fmt.Println("Loading directory:", a.currentDir)
-	
+
 	// Use default filters
+	var globalIgnorePatterns []string
+	if a.prefs != nil {
+		globalIgnorePatterns = a.prefs.GetGlobalIgnorePatterns()
+	}
+
 	filters := fileutils.FileFilters{
 		// No filters - show all files
-		RespectGitignore: true, // Respect .gitignore files by default
+		RespectGitignore:     true, // Respect .gitignore files by default
+		HiddenFiles:          a.hiddenFilesPolicy,
+		FollowSymlinks:       a.followSymlinks,
+		GlobalIgnorePatterns: globalIgnorePatterns,
+	}
+
+	// The heuristic stays on the fast size/4 path (Tokenizer left nil);
+	// any other selection reads and tokenizes file content as it's walked.
+	if a.selectedTokenizer != "" && a.selectedTokenizer != "heuristic" {
+		if tok, err := a.tokenizerRegistry.Get(a.selectedTokenizer); err == nil {
+			filters.Tokenizer = tok
+		}
 	}
-	
+
 	// Load files
 	err := a.fileTree.LoadDirectory(a.currentDir, filters)
 	if err != nil {
 		dialog.ShowError(err, a.window)
 		return
 	}
-	
+
 	// Update token estimation
 	a.updateTokenEstimation()
 }
 
+// updateTokenEstimation tokenizes the current selection against the chosen
+// model's real encoding (rather than the old bytes/4 heuristic), shows a
+// live per-file breakdown in the file tree, and — when the total exceeds
+// the entered limit — suggests files to drop and highlights them red.
 func (a *App) updateTokenEstimation() {
-	// Get selected files
 	selectedFiles := a.fileTree.GetSelectedFiles()
 	if len(selectedFiles) == 0 {
 		a.tokenEstimation.SetText("Estimated Tokens: 0")
+		a.fileTree.SetOverBudget(nil)
 		return
 	}
-	
-	// Don't generate XML here, just estimate based on file sizes
-	totalSize := 0
-	for _, file := range selectedFiles {
-		if !file.IsDir {
-			// Estimate based on file size
-			info, err := os.Stat(file.Path)
-			if err == nil {
-				totalSize += int(info.Size())
+
+	limit, err := strconv.Atoi(a.limitEntry.Text)
+	if err != nil || limit <= 0 {
+		limit = 8192 // Default for GPT-4
+	}
+
+	source := a.fileTree.Source()
+	go func() {
+		total, countErr := a.tokenBudget.CountFiles(selectedFiles, source)
+		if countErr != nil {
+			fyne.Do(func() {
+				a.tokenEstimation.SetText(fmt.Sprintf("Estimated Tokens: error counting some files (%v)", countErr))
+			})
+			return
+		}
+
+		formattedTotal := fileutils.FormatTokenCount(total)
+		statusText := fmt.Sprintf("Estimated Tokens: %s (%s)", formattedTotal, a.selectedModel)
+
+		var overBudget map[string]bool
+		if total > limit {
+			suggestion := prompt.SuggestDrops(selectedFiles, total-limit, prompt.DropLargestFirst)
+			overBudget = make(map[string]bool, len(suggestion.Files))
+			var names []string
+			for _, f := range suggestion.Files {
+				overBudget[f.Path] = true
+				names = append(names, filepath.Base(f.Path))
 			}
+			statusText = fmt.Sprintf("Estimated Tokens: %s exceeds limit of %s — consider dropping: %s",
+				formattedTotal, fileutils.FormatTokenCount(limit), strings.Join(names, ", "))
 		}
-	}
-	
-	// Rough estimate: 1 token per 4 characters
-	estimatedTokens := totalSize / 4
-	
-	// Format the token count
-	formattedTokens := fileutils.FormatTokenCount(estimatedTokens)
-	
-	// Update token estimation label
-	a.tokenEstimation.SetText(fmt.Sprintf("Estimated Tokens: ~%s (rough estimate)", formattedTokens))
-	
-	// Check if exceeds limit
-	limit := 8192 // Default for GPT-4
-	if estimatedTokens > limit {
-		a.tokenEstimation.SetText(fmt.Sprintf("Estimated Tokens: ~%s (exceeds limit of %s)", 
-			formattedTokens, fileutils.FormatTokenCount(limit)))
-	}
+
+		fyne.Do(func() {
+			a.tokenEstimation.SetText(statusText)
+			a.fileTree.SetOverBudget(overBudget)
+		})
+	}()
 }
 
-func (a *App) generateAndCopy() {
-	// Get selected files
+// generateWithProgress runs the shared generate pipeline behind a
+// cancellable progress dialog and calls onDone with the result once it
+// finishes, is cancelled, or fails. For the "xml" format this streams
+// through GenerateStream, reporting real per-file progress; other formats
+// still go through the buffered prompt.Generate, since only XML output has
+// been redesigned to stream so far.
+func (a *App) generateWithProgress(onDone func(generated string, err error)) {
 	selectedFiles := a.fileTree.GetSelectedFiles()
 	if len(selectedFiles) == 0 {
 		dialog.ShowInformation("Error", "No files selected. Please select files first.", a.window)
 		return
 	}
-	
-	// Create a progress dialog
-	progress := dialog.NewProgress("Generating XML", "Processing files...", a.window)
-	progress.Show()
-	
-	// Generate XML in a goroutine to keep UI responsive
+
+	ctx, cancel := context.WithCancel(context.Background())
+	source := a.fileTree.Source()
+
+	statusLabel := widget.NewLabel("Starting...")
+	progressBar := widget.NewProgressBar()
+	cancelButton := widget.NewButton("Cancel", cancel)
+
+	progressDialog := dialog.NewCustomWithoutButtons("Generating Prompt",
+		container.NewVBox(statusLabel, progressBar, cancelButton), a.window)
+	progressDialog.Show()
+
 	go func() {
-		// Generate XML
-		xmlPrompt, err := prompt.GenerateXML(selectedFiles, a.instructionsEntry.Text, a.currentDir)
-		
-		// Save XML for later use
-		a.xmlPrompt = xmlPrompt
-		
-		// Complete the progress
-		progress.SetValue(1.0)
-		
-		// Small delay to ensure progress bar shows completion
-		time.Sleep(100 * time.Millisecond)
-		
-		// Hide the progress dialog
-		progress.Hide()
-		
-		// Handle errors or continue
+		var generated string
+		var err error
+
+		if a.selectedFormat == "xml" {
+			var buf bytes.Buffer
+			var progressCh <-chan prompt.Progress
+			progressCh, err = prompt.GenerateStream(ctx, selectedFiles, a.instructionsEntry.Text, a.currentDir, source, &buf)
+			if err == nil {
+				for p := range progressCh {
+					p := p
+					fyne.Do(func() {
+						if p.FilesTotal > 0 {
+							progressBar.SetValue(float64(p.FilesDone) / float64(p.FilesTotal))
+						}
+						statusLabel.SetText(fmt.Sprintf("%s (%d/%d)", p.Path, p.FilesDone, p.FilesTotal))
+					})
+					if p.Stage == prompt.StageCancelled {
+						err = p.Err
+					}
+				}
+				generated = buf.String()
+			}
+		} else {
+			generated, err = prompt.Generate(selectedFiles, a.instructionsEntry.Text, a.currentDir, a.selectedFormat, source)
+		}
+
+		fyne.Do(func() {
+			progressDialog.Hide()
+			a.updateCacheStatus()
+		})
+
+		onDone(generated, err)
+	}()
+}
+
+func (a *App) generateAndCopy() {
+	a.generateWithProgress(func(generated string, err error) {
+		a.generatedPrompt = generated
+
 		if err != nil {
-			// We need to use the main thread for dialog operations
 			fyne.CurrentApp().SendNotification(&fyne.Notification{
 				Title:   "Error",
 				Content: err.Error(),
 			})
 			return
 		}
-		
-		// Copy to clipboard
-		err = prompt.CopyToClipboard(a.xmlPrompt)
-		if err != nil {
+
+		if copyErr := prompt.CopyToClipboard(a.generatedPrompt); copyErr != nil {
 			fyne.CurrentApp().SendNotification(&fyne.Notification{
 				Title:   "Error",
-				Content: "Failed to copy to clipboard: " + err.Error(),
+				Content: "Failed to copy to clipboard: " + copyErr.Error(),
 			})
 			return
 		}
-		
-		// Show success notification
+
 		fyne.CurrentApp().SendNotification(&fyne.Notification{
 			Title:   "Success",
-			Content: "XML prompt copied to clipboard",
+			Content: "Prompt copied to clipboard",
 		})
-	}()
+	})
 }
 
 func (a *App) previewXML() {
-	// Get selected files
-	selectedFiles := a.fileTree.GetSelectedFiles()
-	if len(selectedFiles) == 0 {
-		dialog.ShowInformation("Error", "No files selected. Please select files first.", a.window)
-		return
-	}
-	
-	// Create a progress dialog
-	progress := dialog.NewProgress("Generating XML", "Processing files...", a.window)
-	progress.Show()
-	
-	// Generate XML in a goroutine to keep UI responsive
-	go func() {
-		// Generate XML
-		xmlPrompt, err := prompt.GenerateXML(selectedFiles, a.instructionsEntry.Text, a.currentDir)
-		
-		// Save XML for later use
-		a.xmlPrompt = xmlPrompt
-		
-		// Complete the progress
-		progress.SetValue(1.0)
-		
-		// Small delay to ensure progress bar shows completion
-		time.Sleep(100 * time.Millisecond)
-		
-		// Hide the progress dialog
-		progress.Hide()
-		
-		// Handle errors
+	a.generateWithProgress(func(generated string, err error) {
+		a.generatedPrompt = generated
+
 		if err != nil {
 			fyne.CurrentApp().SendNotification(&fyne.Notification{
 				Title:   "Error",
@@ -427,16 +665,13 @@ func (a *App) previewXML() {
 			})
 			return
 		}
-		
-		// We need to return to the main thread for UI operations
-		// For now, we'll just show a notification to check the console
+
 		fyne.CurrentApp().SendNotification(&fyne.Notification{
-			Title:   "XML Preview",
-			Content: "XML preview is ready. Check the console output.",
+			Title:   "Prompt Preview",
+			Content: "Prompt preview is ready. Check the console output.",
 		})
-		
-		// Print the XML to console for now
-		fmt.Println("XML Preview:")
-		fmt.Println(a.xmlPrompt)
-	}()
+
+		fmt.Println("Prompt Preview:")
+		fmt.Println(a.generatedPrompt)
+	})
 }
@@ -1,11 +1,15 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
-	
+	"sync"
+	"time"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/openprompt/internal/fileutils"
@@ -17,10 +21,17 @@ type FileTreeWidget struct {
 	container    *fyne.Container
 	files        []*fileutils.FileInfo
 	onChanged    func()
-	expandedDirs map[string]bool // Track expanded directories
-	currentDir   string          // Current root directory
-	filters      fileutils.FileFilters // Current filters
+	expandedDirs map[string]bool          // Track expanded directories
+	currentDir   string                   // Current root directory
+	source       fileutils.Source         // Source currentDir was loaded through (a directory or an archive)
+	filters      fileutils.FileFilters    // Current filters
 	checkboxes   map[string]*widget.Check // Track checkboxes for direct updates
+	cancelWalk   context.CancelFunc       // Aborts the in-flight directory scan, if any
+
+	scanMu   sync.Mutex // guards scanDone
+	scanDone bool       // true once the most recent LoadDirectory scan has finished
+
+	overBudget map[string]bool // paths the token budget suggests dropping; highlighted red
 }
 
 // NewFileTreeWidget creates a new file tree widget
@@ -31,10 +42,10 @@ func NewFileTreeWidget(onChanged func()) *FileTreeWidget {
 		checkboxes:   make(map[string]*widget.Check),
 	}
 	t.ExtendBaseWidget(t)
-	
+
 	// Create an empty container
 	t.container = container.NewVBox()
-	
+
 	return t
 }
 
@@ -42,10 +53,10 @@ func NewFileTreeWidget(onChanged func()) *FileTreeWidget {
 func (t *FileTreeWidget) CreateRenderer() fyne.WidgetRenderer {
 	scroll := container.NewScroll(t.container)
 	scroll.SetMinSize(fyne.NewSize(300, 700)) // Set larger minimum size
-	
+
 	// Create a border container to make the file tree more visible
 	border := container.NewBorder(nil, nil, nil, nil, scroll)
-	
+
 	return widget.NewSimpleRenderer(border)
 }
 
@@ -54,34 +65,86 @@ func (t *FileTreeWidget) MinSize() fyne.Size {
 	return fyne.NewSize(300, 700)
 }
 
-// LoadDirectory loads files from a directory
+// LoadDirectory loads dir's tree into the widget, rendering rows
+// incrementally as they're discovered instead of blocking until the whole
+// tree has been scanned. Calling it again while a scan is still in flight
+// (e.g. the user switches directories) cancels the previous one. dir may be
+// a plain directory or an archive (.zip/.tar/.tar.gz/.tar.bz2) —
+// fileutils.NewSource dispatches on which.
 func (t *FileTreeWidget) LoadDirectory(dir string, filters fileutils.FileFilters) error {
-	// Store current directory and filters for later use
-	t.currentDir = dir
-	t.filters = filters
-	
-	// List only the top-level files with the given filters
-	files, err := fileutils.ListFiles(dir, filters)
+	source, err := fileutils.NewSource(dir)
 	if err != nil {
 		return err
 	}
-	
-	// Build file tree but only for the top level
-	t.files = fileutils.BuildFileTree(files)
-	
-	// Calculate token counts for directories
-	for _, file := range t.files {
-		if file.IsDir {
-			fileutils.CalculateDirectoryTokenCount(file)
-		}
+
+	t.source = source
+	if t.cancelWalk != nil {
+		t.cancelWalk()
 	}
-	
-	// Debug output
-	fmt.Printf("Loaded %d root files/directories\n", len(t.files))
-	
-	// Rebuild the UI directly to avoid nil pointer issues
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancelWalk = cancel
+
+	t.currentDir = dir
+	t.filters = filters
+	t.files = nil
 	t.rebuildUI()
-	
+
+	t.scanMu.Lock()
+	t.scanDone = false
+	t.scanMu.Unlock()
+
+	go func() {
+		var flat []*fileutils.FileInfo
+		var lastErr error
+		discovered := 0
+
+		pushUpdate := func() {
+			tree := fileutils.BuildFileTree(flat)
+			for _, file := range tree {
+				if file.IsDir {
+					fileutils.CalculateDirectoryTokenCount(file)
+				}
+			}
+			fyne.Do(func() {
+				t.files = tree
+				t.rebuildUI()
+			})
+		}
+
+		for res := range source.Walk(ctx, dir, filters) {
+			if res.Err != nil {
+				lastErr = res.Err
+				continue
+			}
+
+			flat = append(flat, res.Info)
+			discovered++
+
+			// Refresh every so often rather than on every single entry, so a
+			// large tree doesn't thrash the UI thread with rebuilds.
+			if discovered%25 == 0 {
+				pushUpdate()
+			}
+		}
+
+		// A scan superseded by a newer LoadDirectory call neither publishes
+		// its partial tree nor marks the newer scan as done.
+		if ctx.Err() != nil {
+			return
+		}
+
+		pushUpdate()
+
+		t.scanMu.Lock()
+		t.scanDone = true
+		t.scanMu.Unlock()
+
+		if lastErr != nil {
+			fmt.Printf("Error walking %s: %v\n", dir, lastErr)
+		}
+		fmt.Printf("Loaded %d root files/directories\n", len(t.files))
+	}()
+
 	return nil
 }
 
@@ -89,23 +152,61 @@ func (t *FileTreeWidget) LoadDirectory(dir string, filters fileutils.FileFilters
 func (t *FileTreeWidget) rebuildUI() {
 	// Clear the container
 	t.container.RemoveAll()
-	
+
 	// Add all root files
 	for _, file := range t.files {
 		t.addFileToUI(file, 0)
 	}
-	
+
 	// Refresh the widget
 	t.Refresh()
 }
 
+// tooltipLabel is a widget.Label that also shows reason in a small popup
+// while the mouse hovers over it. Fyne's core widgets have no built-in
+// tooltip, so this wraps the one case that needs one: explaining which
+// ignore rule greyed out a file tree entry.
+type tooltipLabel struct {
+	widget.Label
+	reason string
+	popup  *widget.PopUp
+}
+
+func newTooltipLabel(text, reason string) *tooltipLabel {
+	l := &tooltipLabel{reason: reason}
+	l.Text = text
+	l.ExtendBaseWidget(l)
+	return l
+}
+
+func (l *tooltipLabel) MouseIn(e *desktop.MouseEvent) {
+	if l.reason == "" {
+		return
+	}
+	canvas := fyne.CurrentApp().Driver().CanvasForObject(l)
+	if canvas == nil {
+		return
+	}
+	l.popup = widget.NewPopUp(widget.NewLabel(l.reason), canvas)
+	l.popup.ShowAtPosition(e.AbsolutePosition.Add(fyne.NewPos(8, 8)))
+}
+
+func (l *tooltipLabel) MouseMoved(e *desktop.MouseEvent) {}
+
+func (l *tooltipLabel) MouseOut() {
+	if l.popup != nil {
+		l.popup.Hide()
+		l.popup = nil
+	}
+}
+
 // addFileToUI adds a file to the UI
 func (t *FileTreeWidget) addFileToUI(file *fileutils.FileInfo, indent int) {
 	// Create a checkbox for selection
 	check := widget.NewCheck("", nil) // Initialize with nil to prevent recursive calls
 	check.OnChanged = func(checked bool) {
 		file.Selected = checked
-		
+
 		// If it's a directory, select/unselect all children
 		if file.IsDir {
 			// Use a goroutine for potentially expensive operations
@@ -114,10 +215,10 @@ func (t *FileTreeWidget) addFileToUI(file *fileutils.FileInfo, indent int) {
 				if checked && len(file.Children) == 0 {
 					t.loadChildren(file)
 				}
-				
+
 				// Select/unselect all children
 				t.toggleSelection(file, checked)
-				
+
 				// Notify of change on the main thread
 				fyne.CurrentApp().Driver().CanvasForObject(t).Content().Refresh()
 				if t.onChanged != nil {
@@ -132,22 +233,28 @@ func (t *FileTreeWidget) addFileToUI(file *fileutils.FileInfo, indent int) {
 		}
 	}
 	check.Checked = file.Selected
-	
+	if file.Ignored {
+		// An ignored entry is shown, not hidden, but it's not something
+		// generation will ever include (see prompt.AssembleFiles), so
+		// selecting it would be misleading.
+		check.Disable()
+	}
+
 	// Store the checkbox for direct updates
 	t.checkboxes[file.Path] = check
-	
+
 	// Create a label for the file name
 	name := filepath.Base(file.Path)
 	if name == "" {
 		name = file.Path // Use full path if base name is empty
 	}
-	
+
 	// Create indentation
 	indentStr := ""
 	for i := 0; i < indent; i++ {
 		indentStr += "    "
 	}
-	
+
 	// Use different icon based on file type
 	var icon fyne.Resource
 	if file.IsDir {
@@ -155,34 +262,51 @@ func (t *FileTreeWidget) addFileToUI(file *fileutils.FileInfo, indent int) {
 	} else {
 		icon = theme.DocumentIcon()
 	}
-	
-	// Create token count label with formatted count
+
+	// Create token count label with formatted count, highlighted if the
+	// token budget suggested dropping this file
 	tokenLabel := widget.NewLabel(fmt.Sprintf("[%s tokens]", fileutils.FormatTokenCount(file.TokenCount)))
-	
+	if t.overBudget[file.Path] {
+		tokenLabel.Importance = widget.DangerImportance
+	}
+
+	// An ignored entry's name carries a hover tooltip explaining which rule
+	// excluded it, and both labels are greyed out (LowImportance) so it
+	// reads as present-but-excluded rather than a normal selectable entry.
+	var nameWidget fyne.CanvasObject
+	if file.Ignored {
+		label := newTooltipLabel(name, "Ignored by "+file.IgnoreRule)
+		label.Importance = widget.LowImportance
+		tokenLabel.Importance = widget.LowImportance
+		nameWidget = label
+	} else {
+		nameWidget = widget.NewLabel(name)
+	}
+
 	// Create a container for the file/directory
 	var item *fyne.Container
-	
+
 	if file.IsDir {
 		// For directories, add an expand/collapse button
 		expandButton := widget.NewButtonWithIcon("", theme.NavigateNextIcon(), func() {
 			t.toggleExpand(file)
 		})
-		
+
 		// Set the button icon based on expanded state
 		if t.expandedDirs[file.Path] {
 			expandButton.SetIcon(theme.MoveDownIcon())
 		} else {
 			expandButton.SetIcon(theme.NavigateNextIcon())
 		}
-		
+
 		// Create a container with checkbox, icon, label, token count, and expand button
 		item = container.NewBorder(
-			nil, nil, 
+			nil, nil,
 			container.NewHBox(
 				widget.NewLabel(indentStr),
 				check,
 				widget.NewIcon(icon),
-				widget.NewLabel(name),
+				nameWidget,
 				tokenLabel,
 			),
 			expandButton,
@@ -193,21 +317,21 @@ func (t *FileTreeWidget) addFileToUI(file *fileutils.FileInfo, indent int) {
 			widget.NewLabel(indentStr),
 			check,
 			widget.NewIcon(icon),
-			widget.NewLabel(name),
+			nameWidget,
 			tokenLabel,
 		)
 	}
-	
+
 	// Add the item to the container
 	t.container.Add(item)
-	
+
 	// Add children if this is an expanded directory
 	if file.IsDir && t.expandedDirs[file.Path] {
 		// If children aren't loaded yet, load them now
 		if len(file.Children) == 0 {
 			t.loadChildren(file)
 		}
-		
+
 		// Display children
 		for _, child := range file.Children {
 			t.addFileToUI(child, indent+1)
@@ -221,7 +345,7 @@ func (t *FileTreeWidget) loadChildren(dir *fileutils.FileInfo) {
 	if !dir.IsDir {
 		return
 	}
-	
+
 	// Get the full path to the directory
 	var dirPath string
 	if filepath.IsAbs(dir.Path) {
@@ -231,34 +355,34 @@ func (t *FileTreeWidget) loadChildren(dir *fileutils.FileInfo) {
 		// Otherwise, join with the current directory
 		dirPath = filepath.Join(t.currentDir, dir.Path)
 	}
-	
+
 	// Create filters for this subdirectory
 	subFilters := t.filters
 	subFilters.SubPath = filepath.Base(dir.Path)
-	
+
 	// List files in this directory
 	files, err := fileutils.ListFiles(filepath.Dir(dirPath), subFilters)
 	if err != nil {
 		fmt.Printf("Error loading children for %s: %v\n", dir.Path, err)
 		return
 	}
-	
+
 	// Build file tree for these files
 	children := fileutils.BuildFileTree(files)
-	
+
 	// Set the children
 	dir.Children = children
-	
+
 	// Calculate token counts for child directories
 	for _, child := range dir.Children {
 		if child.IsDir {
 			fileutils.CalculateDirectoryTokenCount(child)
 		}
 	}
-	
+
 	// Update the token count for this directory
 	fileutils.CalculateDirectoryTokenCount(dir)
-	
+
 	fmt.Printf("Loaded %d children for %s\n", len(children), dir.Path)
 }
 
@@ -267,15 +391,15 @@ func (t *FileTreeWidget) toggleExpand(dir *fileutils.FileInfo) {
 	if !dir.IsDir {
 		return
 	}
-	
+
 	// Toggle expanded state
 	t.expandedDirs[dir.Path] = !t.expandedDirs[dir.Path]
-	
+
 	// If we're expanding and there are no children yet, load them
 	if t.expandedDirs[dir.Path] && len(dir.Children) == 0 {
 		t.loadChildren(dir)
 	}
-	
+
 	// Rebuild UI - this is necessary when expanding/collapsing
 	t.rebuildUI()
 }
@@ -284,12 +408,12 @@ func (t *FileTreeWidget) toggleExpand(dir *fileutils.FileInfo) {
 func (t *FileTreeWidget) toggleSelection(file *fileutils.FileInfo, selected bool) {
 	// Update the selection state
 	file.Selected = selected
-	
+
 	// Update the checkbox directly
 	if check, ok := t.checkboxes[file.Path]; ok {
 		check.SetChecked(selected)
 	}
-	
+
 	// If it's a directory, recursively update all children
 	if file.IsDir {
 		// If children aren't loaded yet and we're selecting, load them now
@@ -301,17 +425,17 @@ func (t *FileTreeWidget) toggleSelection(file *fileutils.FileInfo, selected bool
 				fyne.CurrentApp().Driver().CanvasForObject(t).Content().Refresh()
 			}()
 		}
-		
+
 		// Update all children (only for already loaded children)
 		for _, child := range file.Children {
 			// Set the selected state directly without recursion
 			child.Selected = selected
-			
+
 			// Update the checkbox directly if it exists
 			if check, ok := t.checkboxes[child.Path]; ok {
 				check.SetChecked(selected)
 			}
-			
+
 			// Only recurse one level deeper to avoid freezing
 			if child.IsDir && len(child.Children) > 0 {
 				for _, grandchild := range child.Children {
@@ -325,6 +449,96 @@ func (t *FileTreeWidget) toggleSelection(file *fileutils.FileInfo, selected bool
 	}
 }
 
+// ExportPreset captures the current filters, expanded directories, and
+// selection into a Preset and writes it to path.
+func (t *FileTreeWidget) ExportPreset(path string) error {
+	if t.currentDir == "" {
+		return fmt.Errorf("no directory loaded")
+	}
+
+	preset := &fileutils.Preset{
+		RootDir: t.currentDir,
+		Filters: t.filters,
+	}
+
+	for dir, expanded := range t.expandedDirs {
+		if expanded {
+			preset.ExpandedDirs = append(preset.ExpandedDirs, dir)
+		}
+	}
+
+	var selected []*fileutils.FileInfo
+	t.collectSelectedFiles(t.files, &selected)
+	for _, f := range selected {
+		rel, err := filepath.Rel(t.currentDir, f.Path)
+		if err != nil {
+			rel = f.Path
+		}
+		preset.Selected = append(preset.Selected, filepath.ToSlash(rel))
+	}
+
+	return preset.SaveToFile(path)
+}
+
+// ImportPreset loads a Preset from path, loads its root directory with its
+// filters, and reapplies its selection once the scan completes. onApplied,
+// if non-nil, is called from a background goroutine with the diff between
+// the preset's selection and what was actually found once the reload
+// finishes.
+func (t *FileTreeWidget) ImportPreset(path string, onApplied func(fileutils.ApplyResult)) error {
+	preset, err := fileutils.LoadFromFile(path)
+	if err != nil {
+		return err
+	}
+
+	rootDir := preset.ExpandRootDir()
+	if err := t.LoadDirectory(rootDir, preset.Filters); err != nil {
+		return err
+	}
+
+	for _, dir := range preset.ExpandedDirs {
+		t.expandedDirs[dir] = true
+	}
+
+	go func() {
+		for {
+			t.scanMu.Lock()
+			done := t.scanDone
+			t.scanMu.Unlock()
+			if done {
+				break
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		var result fileutils.ApplyResult
+		fyne.DoAndWait(func() {
+			result = preset.Apply(t.files, rootDir)
+			t.rebuildUI()
+		})
+		if onApplied != nil {
+			onApplied(result)
+		}
+	}()
+
+	return nil
+}
+
+// Source returns the fileutils.Source currentDir was loaded through — nil
+// until the first LoadDirectory call, fileutils.OSSource for a plain
+// directory, or an archive-backed Source.
+func (t *FileTreeWidget) Source() fileutils.Source {
+	return t.source
+}
+
+// SetOverBudget marks paths as over the token budget, highlighting their
+// token count labels red on the next rebuild, and rebuilds immediately. A
+// nil or empty set clears the highlighting.
+func (t *FileTreeWidget) SetOverBudget(paths map[string]bool) {
+	t.overBudget = paths
+	t.rebuildUI()
+}
+
 // GetSelectedFiles returns the list of selected files
 func (t *FileTreeWidget) GetSelectedFiles() []*fileutils.FileInfo {
 	var selected []*fileutils.FileInfo
@@ -340,11 +554,11 @@ func (t *FileTreeWidget) collectSelectedFiles(files []*fileutils.FileInfo, selec
 			if file.IsDir && len(file.Children) == 0 {
 				t.loadChildren(file)
 			}
-			
+
 			// Add the file to the selected list
 			*selected = append(*selected, file)
 		}
-		
+
 		// Check children if it's a directory
 		if file.IsDir && file.Children != nil {
 			t.collectSelectedFiles(file.Children, selected)